@@ -0,0 +1,84 @@
+// dataq-repl loads a JSON or YAML document and explores it interactively by means of the fully qualified names of DataQ
+//
+// Usage:
+//
+//	dataq-repl [-sep .] [-syntax dotted] file
+//
+// Paths, wildcard queries and expressions are accepted, the paths of the document are completed by TAB.
+package main
+
+import (
+	"flag"
+	"fmt"
+	dataq "github.com/LosAngeles971/DataQ/pkg"
+	"github.com/chzyer/readline"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	sep := flag.String("sep", dataq.Default_sep, "separator of the fully qualified names")
+	syntaxName := flag.String("syntax", "dotted", "syntax of the paths: dotted, pointer or jsonpath")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: dataq-repl [flags] file")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	syntax, err := dataq.PathSyntax(*syntaxName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dataq-repl:", err)
+		os.Exit(2)
+	}
+	data, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dataq-repl:", err)
+		os.Exit(2)
+	}
+	doc, err := dataq.DecodeDocument(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dataq-repl: %v: %v\n", flag.Arg(0), err)
+		os.Exit(2)
+	}
+	ss, err := newSession(dataq.NewSurfer(dataq.WithSep(*sep), dataq.WithPathSyntax(syntax)), *sep, doc)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dataq-repl:", err)
+		os.Exit(2)
+	}
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:          "dataq> ",
+		AutoComplete:    ss,
+		InterruptPrompt: "^C",
+		EOFPrompt:       ":quit",
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dataq-repl:", err)
+		os.Exit(2)
+	}
+	defer rl.Close()
+	fmt.Fprintf(rl.Stdout(), "%v: %v paths, enter :help\n", flag.Arg(0), len(ss.paths))
+	for {
+		line, err := rl.Readline()
+		if err == readline.ErrInterrupt {
+			continue
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			fmt.Fprintln(rl.Stderr(), "dataq-repl:", err)
+			return
+		}
+		more, err := ss.execute(rl.Stdout(), line)
+		if err != nil {
+			fmt.Fprintln(rl.Stderr(), "error:", err)
+		}
+		if !more {
+			return
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// session.go defines the commands and the completion of the interactive sessions
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	dataq "github.com/LosAngeles971/DataQ/pkg"
+	"io"
+	"sort"
+	"strings"
+)
+
+// help is printed by the :help command
+const help = `Enter a path (Gamma.Omega), a query with wildcards (orders.*.total) or an expression (orders.0.total > 100).
+Commands:
+  :get <path>       value of a field
+  :query <pattern>  values of the fields matching the pattern
+  :eval <expr>      result of the expression
+  :paths [prefix]   reachable paths, optionally starting with prefix
+  :help             this help
+  :quit             exit
+`
+
+// delimiters end the word completed within an expression
+const delimiters = " \t()!=<>+-*/%&|,'\""
+
+type session struct {
+	s     *dataq.Surfer
+	sep   string
+	doc   interface{}
+	paths []string
+	// candidates are the reachable paths and their prefixes ending with the separator, sorted
+	candidates []string
+}
+
+// newSession loads the document and the list of its reachable paths
+func newSession(s *dataq.Surfer, sep string, doc interface{}) (*session, error) {
+	flat, err := s.GetFlatDataOrdered(doc)
+	if err != nil {
+		return nil, err
+	}
+	ss := &session{s: s, sep: sep, doc: doc}
+	prefixes := map[string]bool{}
+	for _, kv := range flat {
+		ss.paths = append(ss.paths, kv.Key)
+		prefixes[kv.Key] = true
+		fields, err := dataq.SplitPath(kv.Key, sep)
+		if err != nil {
+			continue
+		}
+		for i := 1; i < len(fields); i++ {
+			// the prefix ends with the separator, unless the following field is quoted, e.g. Zeta["v1.2"]
+			prefix := dataq.JoinPath(fields[:i], sep)
+			if strings.HasPrefix(dataq.JoinPath(fields[:i+1], sep)[len(prefix):], sep) {
+				prefix += sep
+			}
+			prefixes[prefix] = true
+		}
+	}
+	for p := range prefixes {
+		ss.candidates = append(ss.candidates, p)
+	}
+	sort.Strings(ss.candidates)
+	return ss, nil
+}
+
+// wordStart returns the start of the path under the cursor, where the delimiters within quoted fields
+// (e.g. the quotes of Zeta["v1.2"]) are part of the path
+func wordStart(line []rune, pos int) int {
+	start := 0
+	for i := 0; i < pos; i++ {
+		if line[i] == '[' && i+1 < pos && line[i+1] == '"' {
+			// skips the quoted field up to its closing quote, escapes included
+			for i += 2; i < pos && line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+			continue
+		}
+		if strings.ContainsRune(delimiters, line[i]) {
+			start = i + 1
+		}
+	}
+	return start
+}
+
+// Do implements the AutoCompleter of readline, completing the path under the cursor with the reachable ones
+func (ss *session) Do(line []rune, pos int) ([][]rune, int) {
+	start := wordStart(line, pos)
+	word := string(line[start:pos])
+	if strings.HasPrefix(string(line[:start]), ":") && !strings.Contains(string(line[:start]), " ") {
+		// the name of a command is not completed
+		return nil, 0
+	}
+	result := [][]rune{}
+	for _, c := range ss.candidates {
+		if strings.HasPrefix(c, word) {
+			result = append(result, []rune(c[len(word):]))
+		}
+	}
+	return result, len([]rune(word))
+}
+
+// writeValue writes a value as JSON
+func writeValue(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// get writes the value of a single field
+func (ss *session) get(w io.Writer, name string) error {
+	result, err := ss.s.Query(name, ss.doc)
+	if err != nil {
+		return err
+	}
+	if len(result) != 1 {
+		return fmt.Errorf("field %v not found", name)
+	}
+	return writeValue(w, result[0].Value)
+}
+
+// query writes the values of all the fields matching a pattern
+func (ss *session) query(w io.Writer, pattern string) error {
+	result, err := ss.s.Query(pattern, ss.doc)
+	if err != nil {
+		return err
+	}
+	for _, kv := range result {
+		data, err := json.Marshal(kv.Value)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%v = %s\n", kv.Key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eval writes the result of an expression
+func (ss *session) eval(w io.Writer, expr string) error {
+	result, err := ss.s.Eval(expr, ss.doc)
+	if err != nil {
+		return err
+	}
+	return writeValue(w, result)
+}
+
+// execute runs a line of input and returns false if the session is over.
+// Lines which are not commands are tried as paths or queries first, then as expressions.
+func (ss *session) execute(w io.Writer, line string) (bool, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return true, nil
+	}
+	if strings.HasPrefix(line, ":") {
+		cmd, arg := line, ""
+		if i := strings.IndexAny(line, " \t"); i > 0 {
+			cmd, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		switch cmd {
+		case ":quit", ":q":
+			return false, nil
+		case ":help":
+			_, err := io.WriteString(w, help)
+			return true, err
+		case ":paths":
+			for _, p := range ss.paths {
+				if strings.HasPrefix(p, arg) {
+					if _, err := fmt.Fprintln(w, p); err != nil {
+						return true, err
+					}
+				}
+			}
+			return true, nil
+		case ":get", ":query", ":eval":
+			if arg == "" {
+				return true, fmt.Errorf("missing argument of %v", cmd)
+			}
+			switch cmd {
+			case ":get":
+				return true, ss.get(w, arg)
+			case ":query":
+				return true, ss.query(w, arg)
+			default:
+				return true, ss.eval(w, arg)
+			}
+		default:
+			return true, fmt.Errorf("unknown command %v, enter :help", cmd)
+		}
+	}
+	if result, err := ss.s.Query(line, ss.doc); err == nil && len(result) > 0 {
+		if len(result) == 1 && !strings.Contains(line, "*") {
+			return true, writeValue(w, result[0].Value)
+		}
+		return true, ss.query(w, line)
+	}
+	return true, ss.eval(w, line)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	dataq "github.com/LosAngeles971/DataQ/pkg"
+	"reflect"
+	"testing"
+)
+
+const YY = `
+gamma:
+  omega: test2
+  ypsilon: 10
+orders:
+  - total: 150
+  - total: 50
+`
+
+func getSession(t *testing.T) *session {
+	doc, err := dataq.DecodeDocument([]byte(YY))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss, err := newSession(dataq.NewSurfer(), dataq.Default_sep, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ss
+}
+
+func TestComplete(t *testing.T) {
+	ss := getSession(t)
+	tests := []struct {
+		line     string
+		expected []string
+		length   int
+	}{
+		{"ga", []string{"mma.", "mma.omega", "mma.ypsilon"}, 2},
+		{"gamma.o", []string{"mega"}, 7},
+		{"orders.0.total > ord", []string{"ers.", "ers.0.", "ers.0.total", "ers.1.", "ers.1.total"}, 3},
+		{"upper(gamma.om", []string{"ega"}, 8},
+		{"nothing", []string{}, 7},
+	}
+	for _, test := range tests {
+		candidates, length := ss.Do([]rune(test.line), len([]rune(test.line)))
+		result := []string{}
+		for _, c := range candidates {
+			result = append(result, string(c))
+		}
+		if !reflect.DeepEqual(result, test.expected) || length != test.length {
+			t.Errorf("completion of %v must be %v (%v) not %v (%v)", test.line, test.expected, test.length, result, length)
+		}
+	}
+	if candidates, _ := ss.Do([]rune(":pa"), 3); len(candidates) != 0 {
+		t.Errorf("commands must not be completed: %v", candidates)
+	}
+}
+
+func TestCompleteQuoted(t *testing.T) {
+	doc := map[string]interface{}{
+		"zeta": map[string]interface{}{
+			"v1.2": map[string]interface{}{"a": 1.0},
+			"v2":   2.0,
+		},
+	}
+	ss, err := newSession(dataq.NewSurfer(), dataq.Default_sep, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		line     string
+		expected []string
+		length   int
+	}{
+		{"ze", []string{"ta", "ta.", "ta.v2", `ta["v1.2"].`, `ta["v1.2"].a`}, 2},
+		{`zeta["v1`, []string{`.2"].`, `.2"].a`}, 8},
+		{`zeta["v1.2"].`, []string{"", "a"}, 13},
+		{`zeta.v2 > zeta["v1.2"].a`, []string{""}, 14},
+	}
+	for _, test := range tests {
+		candidates, length := ss.Do([]rune(test.line), len([]rune(test.line)))
+		result := []string{}
+		for _, c := range candidates {
+			result = append(result, string(c))
+		}
+		if !reflect.DeepEqual(result, test.expected) || length != test.length {
+			t.Errorf("completion of %v must be %v (%v) not %v (%v)", test.line, test.expected, test.length, result, length)
+		}
+	}
+}
+
+func TestExecute(t *testing.T) {
+	ss := getSession(t)
+	tests := map[string]string{
+		"gamma.omega":            "\"test2\"\n",
+		"orders.*.total":         "orders.0.total = 150\norders.1.total = 50\n",
+		"orders.0.total > 100":   "true\n",
+		":eval len(gamma.omega)": "5\n",
+		":get orders.1":          "{\n  \"total\": 50\n}\n",
+		":query gamma.*":         "gamma.omega = \"test2\"\ngamma.ypsilon = 10\n",
+		":paths orders":          "orders.0.total\norders.1.total\n",
+		"   ":                    "",
+	}
+	for line, expected := range tests {
+		var out bytes.Buffer
+		more, err := ss.execute(&out, line)
+		if err != nil {
+			t.Errorf("line %v: %v", line, err)
+		}
+		if !more {
+			t.Errorf("line %v must not end the session", line)
+		}
+		if out.String() != expected {
+			t.Errorf("line %v must write %q not %q", line, expected, out.String())
+		}
+	}
+	for _, line := range []string{"gamma.nothing", ":unknown", ":get", "orders.0.total >"} {
+		if _, err := ss.execute(&bytes.Buffer{}, line); err == nil {
+			t.Errorf("line %v must fail", line)
+		}
+	}
+	if more, _ := ss.execute(&bytes.Buffer{}, ":quit"); more {
+		t.Errorf(":quit must end the session")
+	}
+}
@@ -0,0 +1,251 @@
+// dataq reads JSON or YAML documents and accesses their fields by means of the fully qualified names of DataQ
+//
+// Usage:
+//
+//	dataq [flags] get <path> [file]
+//	dataq [flags] flatten [file]
+//	dataq [flags] query <pattern> [file]
+//	dataq [flags] eval <expr> [file]
+//	dataq [flags] diff <file> <file>
+//
+// Documents are read from stdin when the file is missing or it is "-".
+// The exit status is 0 on success, 1 if diff finds differences and 2 on errors.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	dataq "github.com/LosAngeles971/DataQ/pkg"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// exit statuses
+const (
+	EXIT_OK    = 0
+	EXIT_DIFF  = 1
+	EXIT_ERROR = 2
+)
+
+type options struct {
+	sep    string
+	format string
+	syntax string
+}
+
+// newFlagSet returns the flags accepted both before and after the command
+func newFlagSet(stderr io.Writer, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet("dataq", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.sep, "sep", dataq.Default_sep, "separator of the fully qualified names")
+	fs.StringVar(&opts.format, "format", FORMAT_JSON, "output format: json, env, csv or table")
+	fs.StringVar(&opts.syntax, "syntax", "dotted", "syntax of the paths: dotted, pointer or jsonpath")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: dataq [flags] get <path> [file] | flatten [file] | query <pattern> [file] | eval <expr> [file] | diff <file> <file>")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// readDocument decodes the JSON or YAML document read from the given file, or from stdin if it is "-"
+func readDocument(name string, stdin io.Reader) (interface{}, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = ioutil.ReadAll(stdin)
+	} else {
+		data, err = ioutil.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc, err := dataq.DecodeDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	return doc, nil
+}
+
+// arguments checks the number of positional arguments of a command, the optional file defaults to stdin
+func arguments(cmd string, args []string, n int, optional bool) ([]string, error) {
+	if len(args) == n || (optional && len(args) == n+1) {
+		if optional && len(args) == n {
+			args = append(args, "-")
+		}
+		return args, nil
+	}
+	return nil, fmt.Errorf("wrong number of arguments for %v", cmd)
+}
+
+// number returns the value of a number decoded from JSON (float64) or YAML (int, int64, uint64)
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// equal checks if two values of the flat data are equal, numbers are compared whatever their type
+func equal(a interface{}, b interface{}) bool {
+	na, okA := number(a)
+	nb, okB := number(b)
+	if okA && okB {
+		return na == nb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// diff compares the flat data of two documents, the result is sorted by path
+func diff(s *dataq.Surfer, a interface{}, b interface{}) ([]change, error) {
+	flatA, err := s.GetFlatData(a)
+	if err != nil {
+		return nil, err
+	}
+	flatB, err := s.GetFlatData(b)
+	if err != nil {
+		return nil, err
+	}
+	result := []change{}
+	for k, v := range flatA {
+		if w, ok := flatB[k]; !ok {
+			result = append(result, change{Path: k, Change: "removed", Old: v})
+		} else if !equal(v, w) {
+			result = append(result, change{Path: k, Change: "changed", Old: v, New: w})
+		}
+	}
+	for k, w := range flatB {
+		if _, ok := flatA[k]; !ok {
+			result = append(result, change{Path: k, Change: "added", New: w})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result, nil
+}
+
+// run executes the command line and returns the exit status
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error) {
+	opts := &options{}
+	fs := newFlagSet(stderr, opts)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return EXIT_OK, nil
+		}
+		return EXIT_ERROR, err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return EXIT_ERROR, fmt.Errorf("missing command")
+	}
+	cmd := fs.Arg(0)
+	// flags are accepted after the command too
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return EXIT_ERROR, err
+	}
+	syntax, err := dataq.PathSyntax(opts.syntax)
+	if err != nil {
+		return EXIT_ERROR, err
+	}
+	s := dataq.NewSurfer(dataq.WithSep(opts.sep), dataq.WithPathSyntax(syntax))
+	out := &output{s: s, sep: opts.sep, format: opts.format, w: stdout}
+	if err := out.check(); err != nil {
+		return EXIT_ERROR, err
+	}
+	switch cmd {
+	case "get", "query", "eval":
+		params, err := arguments(cmd, fs.Args(), 1, true)
+		if err != nil {
+			return EXIT_ERROR, err
+		}
+		doc, err := readDocument(params[1], stdin)
+		if err != nil {
+			return EXIT_ERROR, err
+		}
+		switch cmd {
+		case "get":
+			result, err := s.Query(params[0], doc)
+			if err != nil {
+				return EXIT_ERROR, err
+			}
+			if len(result) != 1 {
+				return EXIT_ERROR, fmt.Errorf("field %v not found", params[0])
+			}
+			return EXIT_OK, out.value(result[0])
+		case "query":
+			result, err := s.Query(params[0], doc)
+			if err != nil {
+				return EXIT_ERROR, err
+			}
+			return EXIT_OK, out.fields(result)
+		default:
+			result, err := s.Eval(params[0], doc)
+			if err != nil {
+				return EXIT_ERROR, err
+			}
+			return EXIT_OK, out.value(dataq.KV{Key: "result", Value: result})
+		}
+	case "flatten":
+		params, err := arguments(cmd, fs.Args(), 0, true)
+		if err != nil {
+			return EXIT_ERROR, err
+		}
+		doc, err := readDocument(params[0], stdin)
+		if err != nil {
+			return EXIT_ERROR, err
+		}
+		result, err := s.GetFlatDataOrdered(doc)
+		if err != nil {
+			return EXIT_ERROR, err
+		}
+		return EXIT_OK, out.fields(result)
+	case "diff":
+		params, err := arguments(cmd, fs.Args(), 2, false)
+		if err != nil {
+			return EXIT_ERROR, err
+		}
+		if params[0] == "-" && params[1] == "-" {
+			return EXIT_ERROR, fmt.Errorf("only one document can be read from stdin")
+		}
+		a, err := readDocument(params[0], stdin)
+		if err != nil {
+			return EXIT_ERROR, err
+		}
+		b, err := readDocument(params[1], stdin)
+		if err != nil {
+			return EXIT_ERROR, err
+		}
+		result, err := diff(s, a, b)
+		if err != nil {
+			return EXIT_ERROR, err
+		}
+		if err := out.changes(result); err != nil {
+			return EXIT_ERROR, err
+		}
+		if len(result) > 0 {
+			return EXIT_DIFF, nil
+		}
+		return EXIT_OK, nil
+	default:
+		fs.Usage()
+		return EXIT_ERROR, fmt.Errorf("unknown command %v", cmd)
+	}
+}
+
+func main() {
+	status, err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dataq:", err)
+	}
+	os.Exit(status)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	YY = `
+gamma:
+  omega: test2
+  ypsilon: 10
+orders:
+  - total: 150
+    paid: true
+  - total: 50
+    paid: false
+`
+	JJ = `{"gamma": {"omega": "test3", "ypsilon": 10}, "orders": [{"total": 150, "paid": true}]}`
+)
+
+// execute runs dataq with the given arguments and stdin, returning the exit status and the output
+func execute(t *testing.T, stdin string, args ...string) (int, string) {
+	var stdout, stderr bytes.Buffer
+	status, err := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	if err != nil {
+		t.Logf("dataq %v: %v", args, err)
+	}
+	return status, stdout.String()
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"get", "gamma.omega"}, "\"test2\"\n"},
+		{[]string{"-syntax", "jsonpath", "get", "$.orders[1].paid"}, "false\n"},
+		{[]string{"get", "-format", "env", "gamma"}, "GAMMA_OMEGA=\"test2\"\nGAMMA_YPSILON=10\n"},
+		{[]string{"-format", "csv", "query", "orders.*.total"}, "orders.0.total,orders.1.total\n150,50\n"},
+		{[]string{"-format", "table", "query", "orders.*.total"}, "PATH            VALUE\norders.0.total  150\norders.1.total  50\n"},
+		{[]string{"-sep", "/", "flatten", "-"}, "{\n  \"gamma/omega\": \"test2\",\n  \"gamma/ypsilon\": 10,\n  \"orders/0/paid\": true,\n" +
+			"  \"orders/0/total\": 150,\n  \"orders/1/paid\": false,\n  \"orders/1/total\": 50\n}\n"},
+		{[]string{"eval", "orders.0.total + orders.1.total > 150"}, "true\n"},
+	}
+	for _, test := range tests {
+		status, out := execute(t, YY, test.args...)
+		if status != EXIT_OK {
+			t.Errorf("dataq %v must exit with %v not %v", test.args, EXIT_OK, status)
+		}
+		if out != test.expected {
+			t.Errorf("dataq %v must write %q not %q", test.args, test.expected, out)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yaml")
+	b := filepath.Join(dir, "b.json")
+	if err := ioutil.WriteFile(a, []byte(YY), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(b, []byte(JJ), 0600); err != nil {
+		t.Fatal(err)
+	}
+	status, out := execute(t, "", "-format", "csv", "diff", a, b)
+	if status != EXIT_DIFF {
+		t.Errorf("diff must exit with %v not %v", EXIT_DIFF, status)
+	}
+	expected := "change,new,old,path\nchanged,test3,test2,gamma.omega\nremoved,,false,orders.1.paid\nremoved,,50,orders.1.total\n"
+	if out != expected {
+		t.Errorf("diff must write %q not %q", expected, out)
+	}
+	if status, _ := execute(t, YY, "diff", a, "-"); status != EXIT_OK {
+		t.Errorf("diff of the same data must exit with %v not %v", EXIT_OK, status)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := [][]string{
+		{},
+		{"unknown"},
+		{"get"},
+		{"get", "nope"},
+		{"-format", "xml", "flatten"},
+		{"-syntax", "xpath", "flatten"},
+		{"-format", "env", "diff", "-", "-"},
+		{"get", "gamma.omega", "missing.json"},
+	}
+	for _, args := range tests {
+		if status, _ := execute(t, YY, args...); status != EXIT_ERROR {
+			t.Errorf("dataq %v must exit with %v not %v", args, EXIT_ERROR, status)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// output.go defines the formats of the results written by dataq
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	dataq "github.com/LosAngeles971/DataQ/pkg"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// supported output formats
+const (
+	FORMAT_JSON  = "json"
+	FORMAT_ENV   = "env"
+	FORMAT_CSV   = "csv"
+	FORMAT_TABLE = "table"
+)
+
+// change is a difference between the flat data of two documents
+type change struct {
+	Path   string      `json:"path"`
+	Change string      `json:"change"`
+	Old    interface{} `json:"old,omitempty"`
+	New    interface{} `json:"new,omitempty"`
+}
+
+type output struct {
+	s      *dataq.Surfer
+	sep    string
+	format string
+	w      io.Writer
+}
+
+// check verifies that the format is supported
+func (o *output) check() error {
+	switch o.format {
+	case FORMAT_JSON, FORMAT_ENV, FORMAT_CSV, FORMAT_TABLE:
+		return nil
+	default:
+		return fmt.Errorf("unknown format %v", o.format)
+	}
+}
+
+// writeJSON writes the given value as indented JSON
+func (o *output) writeJSON(data []byte) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := o.w.Write(buf.Bytes())
+	return err
+}
+
+// expand replaces the fields holding maps or lists with their primitive fields, for the formats which are flat
+func (o *output) expand(fields []dataq.KV) ([]dataq.KV, error) {
+	result := []dataq.KV{}
+	for _, f := range fields {
+		switch reflect.ValueOf(f.Value).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array:
+			flat, err := o.s.GetFlatDataOrdered(f.Value)
+			if err != nil {
+				return nil, err
+			}
+			for _, sub := range flat {
+				key := f.Key + o.sep + sub.Key
+				if strings.HasPrefix(sub.Key, "[") {
+					// quoted fields have no separator before them
+					key = f.Key + sub.Key
+				}
+				result = append(result, dataq.KV{Key: key, Value: sub.Value})
+			}
+		default:
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// formatCell returns the string form of a value within a table, nil is an empty string
+func formatCell(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// table writes the given rows aligned in columns, the first row is the header
+func (o *output) table(rows [][]string) error {
+	tw := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// value writes a single field, the json format writes only its value
+func (o *output) value(f dataq.KV) error {
+	if o.format == FORMAT_JSON {
+		data, err := json.Marshal(f.Value)
+		if err != nil {
+			return err
+		}
+		return o.writeJSON(data)
+	}
+	return o.fields([]dataq.KV{f})
+}
+
+// fields writes a list of fields, the json format writes an object keeping their order
+func (o *output) fields(fields []dataq.KV) error {
+	if o.format == FORMAT_JSON {
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		for i, f := range fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(f.Key)
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(f.Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+		return o.writeJSON(buf.Bytes())
+	}
+	fields, err := o.expand(fields)
+	if err != nil {
+		return err
+	}
+	data := map[string]interface{}{}
+	for _, f := range fields {
+		data[f.Key] = f.Value
+	}
+	switch o.format {
+	case FORMAT_ENV:
+		return o.s.WriteDotenv(o.w, data, dataq.WithKeyCase(dataq.KEY_UPPER_SNAKE))
+	case FORMAT_CSV:
+		return o.s.WriteCSV(o.w, []map[string]interface{}{data})
+	default:
+		rows := [][]string{{"PATH", "VALUE"}}
+		for _, f := range fields {
+			rows = append(rows, []string{f.Key, formatCell(f.Value)})
+		}
+		return o.table(rows)
+	}
+}
+
+// changes writes the differences between two documents
+func (o *output) changes(changes []change) error {
+	switch o.format {
+	case FORMAT_JSON:
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		return o.writeJSON(data)
+	case FORMAT_CSV:
+		rows := []map[string]interface{}{}
+		for _, c := range changes {
+			rows = append(rows, map[string]interface{}{"path": c.Path, "change": c.Change, "old": c.Old, "new": c.New})
+		}
+		return o.s.WriteCSV(o.w, rows)
+	case FORMAT_TABLE:
+		rows := [][]string{{"PATH", "CHANGE", "OLD", "NEW"}}
+		for _, c := range changes {
+			rows = append(rows, []string{c.Path, c.Change, formatCell(c.Old), formatCell(c.New)})
+		}
+		return o.table(rows)
+	default:
+		return fmt.Errorf("format %v is not supported by diff", o.format)
+	}
+}
@@ -4,7 +4,10 @@ go 1.15
 
 require (
 	code.rocketnine.space/tslocum/godoc-static v0.2.1 // indirect
-	github.com/Knetic/govaluate v3.0.0+incompatible // indirect
-	github.com/sirupsen/logrus v1.8.1 // indirect
+	github.com/BurntSushi/toml v1.3.2
+	github.com/Knetic/govaluate v3.0.0+incompatible
+	github.com/chzyer/readline v0.0.0-20161106042343-c914be64f07d
+	github.com/sirupsen/logrus v1.8.1
 	golang.org/x/tools v0.1.7 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
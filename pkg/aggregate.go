@@ -0,0 +1,120 @@
+// aggregate.go defines the reporting helpers of the Surfer
+package pkg
+
+import (
+	"fmt"
+	"math"
+)
+
+// supported aggregation functions
+const (
+	AGG_SUM   = "sum"
+	AGG_AVG   = "avg"
+	AGG_MIN   = "min"
+	AGG_MAX   = "max"
+	AGG_COUNT = "count"
+)
+
+// AggSpec defines an aggregation function computed over the field with the given fully qualified name.
+// For count the path is optional: if given only the records where the field is reachable are counted, otherwise all of them.
+type AggSpec struct {
+	Func string
+	Path string
+}
+
+// accumulator collects the values of a single aggregation for a single group
+type accumulator struct {
+	fn    string
+	sum   float64
+	min   float64
+	max   float64
+	count int
+}
+
+func (a *accumulator) add(f float64) {
+	if a.count == 0 {
+		a.min = f
+		a.max = f
+	}
+	a.sum += f
+	a.min = math.Min(a.min, f)
+	a.max = math.Max(a.max, f)
+	a.count++
+}
+
+func (a *accumulator) result() float64 {
+	switch a.fn {
+	case AGG_SUM:
+		return a.sum
+	case AGG_AVG:
+		return a.sum / float64(a.count)
+	case AGG_MIN:
+		return a.min
+	case AGG_MAX:
+		return a.max
+	default:
+		return float64(a.count)
+	}
+}
+
+// Aggregate computes the given aggregations over a slice of records, grouping them by the values of the groupBy fields.
+// The result is a flat map whose keys are the group's values followed by the name of the aggregation,
+// joined as a fully qualified name, e.g. "IT.total" for groupBy "Customer.Country" and aggregation "total".
+// Without groupBy the keys are just the names of the aggregations. All the results are float64.
+// The values of secret groupBy fields are masked as by Get, so the masking MaskFull merges their groups while MaskHash does not.
+func (s Surfer) Aggregate(records interface{}, groupBy []string, aggs map[string]AggSpec) (map[string]interface{}, error) {
+	for name, spec := range aggs {
+		switch spec.Func {
+		case AGG_SUM, AGG_AVG, AGG_MIN, AGG_MAX:
+			if spec.Path == "" {
+				return nil, fmt.Errorf("aggregation %v requires a path", name)
+			}
+		case AGG_COUNT:
+		default:
+			return nil, fmt.Errorf("aggregation %v has unsupported function %v", name, spec.Func)
+		}
+	}
+	list, err := getRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	accs := map[string]*accumulator{}
+	for i := 0; i < list.Len(); i++ {
+		record := list.Index(i).Interface()
+		group := ""
+		for _, path := range groupBy {
+			value, err := s.Get(path, record)
+			if err != nil {
+				return nil, fmt.Errorf("record %v: %w", i, err)
+			}
+			group = s.join(group, toString(value))
+		}
+		for name, spec := range aggs {
+			key := s.join(group, name)
+			acc, ok := accs[key]
+			if !ok {
+				acc = &accumulator{fn: spec.Func}
+				accs[key] = acc
+			}
+			if spec.Func == AGG_COUNT {
+				// count only the records where the field is reachable
+				if spec.Path == "" {
+					acc.add(0.0)
+				} else if _, err := s.valueOf(spec.Path, record); err == nil {
+					acc.add(0.0)
+				}
+				continue
+			}
+			f, err := s.GetFloat64(spec.Path, record)
+			if err != nil {
+				return nil, fmt.Errorf("record %v: %w", i, err)
+			}
+			acc.add(f)
+		}
+	}
+	data := map[string]interface{}{}
+	for key, acc := range accs {
+		data[key] = acc.result()
+	}
+	return data, nil
+}
@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Knetic/govaluate"
+)
+
+func TestAggregate(t *testing.T) {
+	s := NewSurfer()
+	data, err := s.Aggregate(getOrders(), []string{"Customer.Country"}, map[string]AggSpec{
+		"total": {Func: AGG_SUM, Path: "Total"},
+		"avg":   {Func: AGG_AVG, Path: "Total"},
+		"min":   {Func: AGG_MIN, Path: "Total"},
+		"max":   {Func: AGG_MAX, Path: "Id"},
+		"n":     {Func: AGG_COUNT},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]float64{
+		"IT.total": 500.0,
+		"IT.avg":   500.0 / 3,
+		"IT.min":   50.0,
+		"IT.max":   4.0,
+		"IT.n":     3.0,
+		"FR.total": 200.0,
+		"FR.avg":   200.0,
+		"FR.min":   200.0,
+		"FR.max":   2.0,
+		"FR.n":     1.0,
+	}
+	if len(data) != len(expected) {
+		t.Errorf("expected %v results not %v", len(expected), len(data))
+	}
+	for k, v := range expected {
+		if data[k] != v {
+			t.Errorf("%v must be %v not %v", k, v, data[k])
+		}
+	}
+}
+
+func TestAggregateWithoutGroups(t *testing.T) {
+	s := NewSurfer(WithSep("_"))
+	data, err := s.Aggregate(getOrders(), nil, map[string]AggSpec{
+		"total": {Func: AGG_SUM, Path: "Total"},
+		"n":     {Func: AGG_COUNT, Path: "Customer_Name"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expr, err := govaluate.NewEvaluableExpression("total / n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := expr.Evaluate(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.(float64) != 175.0 {
+		t.Errorf("average must be 175 not %v", result)
+	}
+}
+
+func TestAggregateErrors(t *testing.T) {
+	s := NewSurfer()
+	if _, err := s.Aggregate(getOrders(), nil, map[string]AggSpec{"x": {Func: "median", Path: "Total"}}); err == nil {
+		t.Error("median is not supported")
+	}
+	if _, err := s.Aggregate(getOrders(), nil, map[string]AggSpec{"x": {Func: AGG_SUM}}); err == nil {
+		t.Error("sum requires a path")
+	}
+	if _, err := s.Aggregate(getOrders(), nil, map[string]AggSpec{"x": {Func: AGG_SUM, Path: "Customer.Name"}}); err == nil {
+		t.Error("names are not numbers")
+	}
+	if _, err := s.Aggregate(getOrders(), nil, map[string]AggSpec{"x": {Func: AGG_SUM, Path: "Customer"}}); !errors.Is(err, ErrWrongType) {
+		t.Errorf("expected a wrong type not %v", err)
+	}
+	if _, err := s.Aggregate(getOrders(), nil, map[string]AggSpec{"x": {Func: AGG_SUM, Path: "Missing"}}); !errors.Is(err, ErrMissingField) {
+		t.Errorf("expected a missing field not %v", err)
+	}
+	if _, err := s.Aggregate(getOrders(), []string{"Customer.Missing"}, map[string]AggSpec{"x": {Func: AGG_COUNT}}); !errors.Is(err, ErrMissingField) {
+		t.Errorf("expected a missing group field not %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build go1.23
+
+// all_go123.go defines the iterator over the fields of a data structure, available since Go 1.23
+package pkg
+
+import (
+	"iter"
+)
+
+// All returns an iterator over the fields of the source with a supported primitive value, in the same order of Walk,
+// and a function returning the error which stopped the last iteration, e.g. because the source is not supported.
+// Breaking the loop stops the visit without any error.
+func (s Surfer) All(source any) (iter.Seq2[string, any], func() error) {
+	var err error
+	seq := func(yield func(string, any) bool) {
+		err = s.Walk(source, func(path string, value interface{}) error {
+			if !yield(path, value) {
+				return ErrStopWalk
+			}
+			return nil
+		})
+	}
+	return seq, func() error {
+		return err
+	}
+}
@@ -0,0 +1,40 @@
+//go:build go1.23
+
+package pkg
+
+import (
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	l1 := getData()
+	s := NewSurfer()
+	kvs, err := s.GetFlatDataOrdered(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := 0
+	all, errAll := s.All(l1)
+	for path, value := range all {
+		if path != kvs[i].Key || value != kvs[i].Value {
+			t.Errorf("field %v must be %v not %v", i, kvs[i], path)
+		}
+		i++
+		if i == 3 {
+			break
+		}
+	}
+	if i != 3 {
+		t.Errorf("expected 3 fields not %v", i)
+	}
+	if err := errAll(); err != nil {
+		t.Fatal(err)
+	}
+	all, errAll = s.All(5)
+	for range all {
+		t.Error("an int has no fields")
+	}
+	if errAll() == nil {
+		t.Error("expected failure because an int is not supported")
+	}
+}
@@ -0,0 +1,174 @@
+// bind.go defines the Surfer's methods setting the fields of a struct from the environment or the command line
+package pkg
+
+import (
+	"flag"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"reflect"
+	"strconv"
+)
+
+// setFromString sets a field of a supported primitive type parsing the given string
+func setFromString(v reflect.Value, str string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(str)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(str, 0, 64)
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(i) {
+			return fmt.Errorf("value %v overflows %v", str, v.Kind())
+		}
+		v.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(str, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("not supported type %v", v.Kind())
+	}
+	return nil
+}
+
+// getTarget returns the struct pointed by target, which must be a non nil pointer
+func getTarget(target interface{}) (reflect.Value, error) {
+	obj := reflect.ValueOf(target)
+	if obj.Kind() != reflect.Ptr || obj.IsNil() {
+		return obj, fmt.Errorf("target must be a non nil pointer not %v", obj.Kind())
+	}
+	if obj.Elem().Kind() != reflect.Struct {
+		return obj, fmt.Errorf("target must point to a struct not %v", obj.Elem().Kind())
+	}
+	return obj.Elem(), nil
+}
+
+// bindLeaves visits the settable leaf fields of a struct, returning true if visit bound at least one of them.
+// Nil pointers to structs are allocated, and kept only if allocate is true or some of their fields got bound.
+// Maps are skipped because their keys cannot be known in advance.
+func (s Surfer) bindLeaves(prefix string, obj reflect.Value, allocate bool, stack map[reflect.Type]bool, visit func(string, reflect.Value) (bool, error)) (bool, error) {
+	bound := false
+	stack[obj.Type()] = true
+	defer delete(stack, obj.Type())
+	for i := 0; i < obj.NumField(); i++ {
+		f_name := obj.Type().Field(i).Name
+		if !checkFieldName(f_name) {
+			continue
+		}
+		f_value := obj.Field(i)
+		path := s.join(prefix, f_name)
+		switch f_value.Kind() {
+		case reflect.Float64, reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float32:
+			ok, err := visit(path, f_value)
+			if err != nil {
+				return bound, err
+			}
+			bound = bound || ok
+		case reflect.Struct:
+			ok, err := s.bindLeaves(path, f_value, allocate, stack, visit)
+			if err != nil {
+				return bound, err
+			}
+			bound = bound || ok
+		case reflect.Ptr:
+			elem := f_value.Type().Elem()
+			if elem.Kind() != reflect.Struct || stack[elem] {
+				// recursive types are not expanded
+				log.Debugf("skipped field [%v] of type %v", path, f_value.Type())
+				continue
+			}
+			sub := f_value
+			if f_value.IsNil() {
+				sub = reflect.New(elem)
+			}
+			ok, err := s.bindLeaves(path, sub.Elem(), allocate, stack, visit)
+			if err != nil {
+				return bound, err
+			}
+			if f_value.IsNil() && (ok || allocate) {
+				f_value.Set(sub)
+			}
+			bound = bound || ok
+		default:
+			log.Debugf("skipped field [%v] of type %v", path, f_value.Kind())
+		}
+	}
+	return bound, nil
+}
+
+// BindEnv sets the fields of the struct pointed by target from the environment variables.
+// The name of each variable is the prefix followed by the fully qualified name of the field in the KEY_UPPER_SNAKE form,
+// e.g. APP_GAMMA_OMEGA for the prefix "APP" and the field "Gamma.Omega". Without prefix it is just GAMMA_OMEGA.
+// Nil pointers to structs are allocated only if at least one of their fields is set.
+func (s Surfer) BindEnv(target interface{}, prefix string) error {
+	obj, err := getTarget(target)
+	if err != nil {
+		return err
+	}
+	_, err = s.bindLeaves("", obj, false, map[reflect.Type]bool{}, func(path string, field reflect.Value) (bool, error) {
+		name := sanitizeEnvName(transformKey(path, s.sep, KEY_UPPER_SNAKE))
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return false, nil
+		}
+		if err := setFromString(field, value); err != nil {
+			return false, fmt.Errorf("environment variable %v: %v", name, err)
+		}
+		return true, nil
+	})
+	return err
+}
+
+// fieldFlag is a flag.Value setting a field of a struct
+type fieldFlag struct {
+	field reflect.Value
+}
+
+func (f fieldFlag) String() string {
+	if !f.field.IsValid() {
+		return ""
+	}
+	return formatValue(f.field.Interface())
+}
+
+func (f fieldFlag) Set(value string) error {
+	return setFromString(f.field, value)
+}
+
+func (f fieldFlag) IsBoolFlag() bool {
+	return f.field.IsValid() && f.field.Kind() == reflect.Bool
+}
+
+// BindFlags registers on the flag set an entry for each leaf field of the struct pointed by target.
+// The name of each flag is the prefix followed by the fully qualified name of the field in the KEY_LOWER form,
+// e.g. "app.gamma.omega" for the prefix "app" and the field "Gamma.Omega". The current values of the fields are the defaults.
+// Nil pointers to structs are allocated, because the flags are parsed later.
+func (s Surfer) BindFlags(fs *flag.FlagSet, target interface{}, prefix string) error {
+	obj, err := getTarget(target)
+	if err != nil {
+		return err
+	}
+	_, err = s.bindLeaves(prefix, obj, true, map[reflect.Type]bool{}, func(path string, field reflect.Value) (bool, error) {
+		name := transformKey(path, s.sep, KEY_LOWER)
+		if fs.Lookup(name) != nil {
+			return false, fmt.Errorf("flag %v is already defined", name)
+		}
+		fs.Var(fieldFlag{field: field}, name, "sets the field "+path)
+		return true, nil
+	})
+	return err
+}
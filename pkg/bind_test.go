@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"flag"
+	"os"
+	"testing"
+)
+
+type Node struct {
+	Name string
+	Next *Node
+}
+
+type Config struct {
+	Alfa   float64
+	Gamma  *Level2
+	Server struct {
+		Port  int
+		Debug bool
+	}
+	Root *Node
+}
+
+func TestBindEnv(t *testing.T) {
+	env := map[string]string{
+		"APP_ALFA":          "2.5",
+		"APP_GAMMA_OMEGA":   "from env",
+		"APP_SERVER_PORT":   "8080",
+		"APP_SERVER_DEBUG":  "true",
+		"APP_GAMMA_YPSILON": "0x10",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	c := Config{}
+	s := NewSurfer()
+	if err := s.BindEnv(&c, "APP"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Alfa != 2.5 || c.Server.Port != 8080 || !c.Server.Debug {
+		t.Errorf("unexpected config %+v", c)
+	}
+	if c.Gamma == nil || c.Gamma.Omega != "from env" || c.Gamma.Ypsilon != 16 {
+		t.Errorf("unexpected gamma %+v", c.Gamma)
+	}
+	if c.Gamma.Epsilon != nil || c.Root != nil {
+		t.Error("pointers without environment variables must stay nil")
+	}
+}
+
+func TestBindEnvErrors(t *testing.T) {
+	os.Setenv("BAD_SERVER_PORT", "eighty")
+	defer os.Unsetenv("BAD_SERVER_PORT")
+	s := NewSurfer()
+	c := Config{}
+	if err := s.BindEnv(&c, "BAD"); err == nil {
+		t.Error("eighty is not an int")
+	}
+	if err := s.BindEnv(c, "BAD"); err == nil {
+		t.Error("target must be a pointer")
+	}
+}
+
+func TestBindFlags(t *testing.T) {
+	c := Config{Alfa: 1.0}
+	s := NewSurfer()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := s.BindFlags(fs, &c, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Parse([]string{"-gamma.omega", "from flag", "-server.debug", "-root.name", "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Alfa != 1.0 || c.Gamma.Omega != "from flag" || !c.Server.Debug || c.Root.Name != "first" {
+		t.Errorf("unexpected config %+v", c)
+	}
+	if fs.Lookup("root.next.name") != nil {
+		t.Error("recursive types must not be expanded")
+	}
+	if err := s.BindFlags(fs, &c, ""); err == nil {
+		t.Error("flags are already defined")
+	}
+}
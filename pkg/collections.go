@@ -0,0 +1,72 @@
+// collections.go defines the Surfer's methods working on collections of records
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// getRecords returns the slice or array of records given directly or by means of a pointer
+func getRecords(records interface{}) (reflect.Value, error) {
+	obj := reflect.ValueOf(records)
+	if obj.Kind() == reflect.Ptr {
+		obj = obj.Elem()
+	}
+	switch obj.Kind() {
+	case reflect.Slice, reflect.Array:
+		return obj, nil
+	default:
+		return obj, fmt.Errorf("records must be a slice or an array not %v", obj.Kind())
+	}
+}
+
+// Filter returns the records satisfying the given predicate, which is an expression as accepted by Eval.
+// The result is a slice with the same element type of the given records.
+func (s Surfer) Filter(records interface{}, predicate string) (interface{}, error) {
+	list, err := getRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.compile(predicate)
+	if err != nil {
+		return nil, err
+	}
+	result := reflect.MakeSlice(reflect.SliceOf(list.Type().Elem()), 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		record := list.Index(i)
+		value, err := e.evaluate(record.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("record %v: %w", i, err)
+		}
+		ok, isBool := value.(bool)
+		if !isBool {
+			return nil, fmt.Errorf("record %v: predicate must be a bool not %v", i, value)
+		}
+		if ok {
+			result = reflect.Append(result, record)
+		}
+	}
+	return result.Interface(), nil
+}
+
+// Select returns, for each record, a map including only the fields with the given fully qualified names,
+// the secret ones masked as by Get
+func (s Surfer) Select(records interface{}, paths ...string) ([]map[string]interface{}, error) {
+	list, err := getRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]interface{}, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		data := map[string]interface{}{}
+		for _, path := range paths {
+			value, err := s.Get(path, list.Index(i).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("record %v: %w", i, err)
+			}
+			data[path] = value
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
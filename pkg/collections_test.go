@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const (
+	JJ_orders = `
+[
+	{"customer": {"country": "IT"}, "total": 150},
+	{"customer": {"country": "FR"}, "total": 200},
+	{"customer": {"country": "IT"}, "total": 50}
+]
+`
+)
+
+type Customer struct {
+	Name    string
+	Country string
+}
+
+type Order struct {
+	Id       int
+	Customer *Customer
+	Total    float64
+}
+
+func getOrders() []Order {
+	return []Order{
+		{Id: 1, Customer: &Customer{Name: "Mario", Country: "IT"}, Total: 150.0},
+		{Id: 2, Customer: &Customer{Name: "Jean", Country: "FR"}, Total: 200.0},
+		{Id: 3, Customer: &Customer{Name: "Luigi", Country: "IT"}, Total: 50.0},
+		{Id: 4, Customer: &Customer{Name: "Anna", Country: "IT"}, Total: 300.0},
+	}
+}
+
+func TestFilter(t *testing.T) {
+	s := NewSurfer()
+	filtered, err := s.Filter(getOrders(), "Customer.Country == 'IT' && Total > 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, ok := filtered.([]Order)
+	if !ok {
+		t.Fatalf("result must be []Order not %T", filtered)
+	}
+	if len(orders) != 2 || orders[0].Id != 1 || orders[1].Id != 4 {
+		t.Errorf("unexpected filtered orders %v", orders)
+	}
+}
+
+func TestFilterUnmarshaledJson(t *testing.T) {
+	var records []interface{}
+	if err := json.Unmarshal([]byte(JJ_orders), &records); err != nil {
+		t.Fatal(err)
+	}
+	s := NewSurfer()
+	filtered, err := s.Filter(&records, "customer.country == 'IT' && total > 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered.([]interface{})) != 1 {
+		t.Errorf("expected 1 record not %v", len(filtered.([]interface{})))
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	s := NewSurfer()
+	if _, err := s.Filter(getData(), "Alfa > 0"); err == nil {
+		t.Error("records must be a slice")
+	}
+	if _, err := s.Filter(getOrders(), "Total + 1"); err == nil {
+		t.Error("predicate must be a bool")
+	}
+	if _, err := s.Filter(getOrders(), "Missing > 1"); !errors.Is(err, ErrMissingField) {
+		t.Errorf("expected a missing field not %v", err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	s := NewSurfer()
+	rows, err := s.Select(getOrders(), "Id", "Customer.Name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows not %v", len(rows))
+	}
+	if rows[1]["Id"] != 2 || rows[1]["Customer.Name"] != "Jean" || len(rows[1]) != 2 {
+		t.Errorf("unexpected row %v", rows[1])
+	}
+	if _, err := s.Select(getOrders(), "Customer.Missing"); !errors.Is(err, ErrMissingField) {
+		t.Errorf("expected a missing field not %v", err)
+	}
+}
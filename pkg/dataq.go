@@ -2,9 +2,13 @@
 package pkg
 
 import (
+	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"reflect"
+	"sort"
+	"strconv"
+	"sync"
 )
 
 const (
@@ -12,7 +16,19 @@ const (
 )
 
 type Surfer struct {
-	sep string
+	sep    string
+	syntax int
+	less   func(a string, b string) bool
+	// patterns given by WithRedact, WithInclude and WithExclude, and the same split into fields
+	redactPatterns  []string
+	redact          [][]string
+	includePatterns []string
+	include         [][]string
+	excludePatterns []string
+	exclude         [][]string
+	masker          Masker
+	// err is the failure of parsing the patterns, returned by the methods using them
+	err error
 }
 
 type SurferOption func(*Surfer)
@@ -24,9 +40,21 @@ func WithSep(sep string) SurferOption {
 	}
 }
 
-// GetFlatData returns a map of interface{} including all fields extracted from the source
-func (s Surfer) GetFlatData(source interface{}) (map[string]interface{}, error) {
-	data := map[string]interface{}{}
+// KV is a field of the flat data: its fully qualified name and its value
+type KV struct {
+	Key   string
+	Value interface{}
+}
+
+// WithKeyOrder sets the comparator used to sort the keys of maps while flattening the data, by default they are sorted as strings
+func WithKeyOrder(less func(a string, b string) bool) SurferOption {
+	return func(s *Surfer) {
+		s.less = less
+	}
+}
+
+// getRoot returns the data structure to be flattened, given directly or by means of a pointer
+func getRoot(source interface{}) (reflect.Value, error) {
 	var obj reflect.Value
 	if reflect.ValueOf(source).Kind() == reflect.Ptr {
 		// this is the case of passing a pointer to a struct because you wanna update a field
@@ -35,64 +63,166 @@ func (s Surfer) GetFlatData(source interface{}) (map[string]interface{}, error)
 		obj = reflect.ValueOf(source)
 	}
 	switch obj.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return obj, nil
+	default:
+		return obj, fmt.Errorf("unhandled type of data %v", obj.Kind())
+	}
+}
+
+// ErrStopWalk can be returned by the function passed to Walk to stop visiting the fields without failing
+var ErrStopWalk = errors.New("stop walk")
+
+// Walk calls fn for each field of the source with a supported primitive value, in the same order of GetFlatDataOrdered,
+// without materialising the flat data. Secret values are masked (see WithRedact) and filters are applied (see WithInclude).
+// If fn returns an error the walk stops and the error is returned, except for ErrStopWalk.
+func (s Surfer) Walk(source interface{}, fn func(path string, value interface{}) error) error {
+	if s.err != nil {
+		return s.err
+	}
+	obj, err := getRoot(source)
+	if err != nil {
+		return err
+	}
+	err = s.walk(s.rootPosition(), obj, fn)
+	if err == ErrStopWalk {
+		return nil
+	}
+	return err
+}
+
+// GetFlatData returns a map of interface{} including all fields extracted from the source
+func (s Surfer) GetFlatData(source interface{}) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	return data, s.Walk(source, func(path string, value interface{}) error {
+		data[path] = value
+		return nil
+	})
+}
+
+// GetFlatDataOrdered returns the same fields of GetFlatData in a deterministic order:
+// fields of structs follow their declaration, keys of maps are sorted (see WithKeyOrder) and elements of lists their index.
+func (s Surfer) GetFlatDataOrdered(source interface{}) ([]KV, error) {
+	data := []KV{}
+	return data, s.Walk(source, func(path string, value interface{}) error {
+		data = append(data, KV{Key: path, Value: value})
+		return nil
+	})
+}
+
+// join returns the fully qualified name of a field given the one of its parent, quoting the name if needed
+func (s Surfer) join(prefix string, name string) string {
+	if needsQuoting(name, s.sep) || prefix == "" {
+		return prefix + formatSegment(name, s.sep)
+	}
+	return prefix + s.sep + name
+}
+
+// sortedMapKeys returns the keys of a map sorted by the comparator of the Surfer
+func (s Surfer) sortedMapKeys(obj reflect.Value) ([]string, map[string]reflect.Value) {
+	names := []string{}
+	keys := map[string]reflect.Value{}
+	for _, k := range obj.MapKeys() {
+		name := keyString(k)
+		names = append(names, name)
+		keys[name] = k
+	}
+	if s.less == nil {
+		sort.Strings(names)
+	} else {
+		sort.SliceStable(names, func(i, j int) bool {
+			return s.less(names[i], names[j])
+		})
+	}
+	return names, keys
+}
+
+// structField is a field of a struct visited by walk
+type structField struct {
+	index  int
+	name   string
+	secret bool
+}
+
+// structFields caches the fields visited by walk for each type of struct, it is shared by all the Surfers and goroutines
+var structFields sync.Map
+
+// fieldsOf returns the fields of a type of struct visited by walk, i.e. the exported ones
+func fieldsOf(t reflect.Type) []structField {
+	if cached, ok := structFields.Load(t); ok {
+		return cached.([]structField)
+	}
+	fields := []structField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !checkFieldName(f.Name) {
+			log.Printf("field %v is not valid, not exported or nil", f.Name)
+			continue
+		}
+		fields = append(fields, structField{index: i, name: f.Name, secret: isSecret(f)})
+	}
+	cached, _ := structFields.LoadOrStore(t, fields)
+	return cached.([]structField)
+}
+
+// walk visits in order all the supported primitive values reachable from obj, which is the field at the given position
+func (s Surfer) walk(pos position, obj reflect.Value, visit func(string, interface{}) error) error {
+	switch obj.Kind() {
+	case reflect.Ptr:
+		if obj.IsNil() {
+			log.Debugf("skipped field to pointer [%v] because nil", pos.name)
+			return nil
+		}
+		return s.walk(pos, obj.Elem(), visit)
+	case reflect.Interface:
+		if obj.IsNil() {
+			// null values of documents (e.g. unmarshaled JSON) are kept
+			if !pos.included {
+				return nil
+			}
+			return visit(pos.name, nil)
+		}
+		return s.walk(pos, obj.Elem(), visit)
 	case reflect.Struct:
-		for i := 0; i < obj.NumField(); i++ {
-			f_value := obj.Field(i)
-			f_name := obj.Type().Field(i).Name
-			// f_value must not be a (struct) zero value
-			if checkFieldName(f_name) && f_value.IsValid() {
-				switch f_value.Kind() {
-				case reflect.Struct, reflect.Ptr:
-					if f_value.IsNil() {
-						log.Debugf("skipped field to struct [%v] because nil", f_name)
-					} else {
-						// the struct's field is a struct ready for a sublevel search
-						subdata, err := s.GetFlatData(f_value.Interface())
-						if err != nil {
-							return data, err
-						}
-						for name, value := range subdata {
-							data[f_name+s.sep+name] = value
-						}
-					}
-				case reflect.Float64, reflect.String, reflect.Bool, reflect.Int, reflect.Float32:
-					// the struct's field is a supported primitive data
-					data[f_name] = f_value.Interface()
-				case reflect.Map:
-					if f_value.IsNil() {
-						log.Debugf("skipped field to map [%v] because nil", f_name)
-					} else {
-						// the struct's field is a map
-						for _, k := range getFieldsFromMap(f_value.Interface()) {
-							value, err := getValueFromMap(k, f_value.Interface())
-							if err != nil {
-								return data, err
-							}
-							data[f_name+s.sep+k] = value
-						}
-					}
-				default:
-					log.Printf("field %v got a not supported type %v", f_name, f_value.Kind())
+		for _, f := range fieldsOf(obj.Type()) {
+			if c, ok := s.enter(pos, f.name, f.secret); ok {
+				if err := s.walk(c, obj.Field(f.index), visit); err != nil {
+					return err
 				}
-			} else {
-				log.Printf("field %v is not valid, not exported or nil", f_name)
 			}
 		}
-		return data, nil
 	case reflect.Map:
-		// starting object is not struct but map
-		keys := getFieldsFromMap(obj)
-		for _, k := range keys {
-			var err error
-			data[k], err = getValueFromMap(k, obj)
-			if err != nil {
-				return data, err
+		if obj.IsNil() {
+			log.Debugf("skipped field to map [%v] because nil", pos.name)
+			return nil
+		}
+		names, keys := s.sortedMapKeys(obj)
+		for _, name := range names {
+			if c, ok := s.enter(pos, name, false); ok {
+				if err := s.walk(c, obj.MapIndex(keys[name]), visit); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		// elements are referenced by their index
+		for i := 0; i < obj.Len(); i++ {
+			if c, ok := s.enter(pos, strconv.Itoa(i), false); ok {
+				if err := s.walk(c, obj.Index(i), visit); err != nil {
+					return err
+				}
 			}
 		}
-		return data, nil
+	case reflect.Float64, reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float32:
+		// supported primitive data
+		if !pos.included {
+			return nil
+		}
+		return visit(pos.name, s.redactValue(pos, obj.Interface()))
 	default:
-		return data, fmt.Errorf("unhandled type of data %v", obj.Kind())
+		log.Printf("field %v got a not supported type %v", pos.name, obj.Kind())
 	}
+	return nil
 }
 
 // NewSurfer creates a pointer to a new Surfer object with default configuration
@@ -103,5 +233,22 @@ func NewSurfer(opts ...SurferOption) *Surfer {
 	for _, opt := range opts {
 		opt(s)
 	}
+	// patterns are parsed once all the options are applied, since they depend on the separator
+	var err error
+	if s.redact, err = parsePatterns(s.redactPatterns, s.sep); err != nil {
+		s.err = err
+	}
+	if s.include, err = parsePatterns(s.includePatterns, s.sep); err != nil && s.err == nil {
+		s.err = err
+	}
+	if s.exclude, err = parsePatterns(s.excludePatterns, s.sep); err != nil && s.err == nil {
+		s.err = err
+	}
 	return s
 }
+
+// Err returns the failure of parsing the patterns given by the options (e.g. WithRedact), which is returned
+// by the methods of the Surfer too, so a configuration can be checked as soon as the Surfer is created
+func (s Surfer) Err() error {
+	return s.err
+}
@@ -48,6 +48,51 @@ func TestGetFlatDatas(t *testing.T) {
 	}
 }
 
+type Shipment struct {
+	Code     string
+	Weight   int64
+	Origin   Customer
+	Parcels  []Customer
+	Tags     [2]string
+	Extra    interface{}
+	Metadata map[string]interface{}
+	Carrier  *Customer
+}
+
+func TestGetFlatDataNested(t *testing.T) {
+	s := NewSurfer()
+	shipment := Shipment{
+		Code:     "s1",
+		Weight:   int64(12),
+		Origin:   Customer{Name: "Alice", Country: "Italy"},
+		Parcels:  []Customer{{Name: "p1"}},
+		Tags:     [2]string{"fragile", "urgent"},
+		Metadata: map[string]interface{}{"note": nil, "dims": map[string]interface{}{"w": 1.5}},
+	}
+	vars, err := s.GetFlatData(shipment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// structs held by value and elements of lists are flattened, the nil values of interfaces are kept,
+	// the nil pointers are skipped and int64 is a supported primitive type
+	expected := map[string]interface{}{
+		"Code":              "s1",
+		"Weight":            int64(12),
+		"Origin.Name":       "Alice",
+		"Origin.Country":    "Italy",
+		"Parcels.0.Name":    "p1",
+		"Parcels.0.Country": "",
+		"Tags.0":            "fragile",
+		"Tags.1":            "urgent",
+		"Extra":             nil,
+		"Metadata.note":     nil,
+		"Metadata.dims.w":   1.5,
+	}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Fatalf("expected %v not %v", expected, vars)
+	}
+}
+
 func TestMath(t *testing.T) {
 	l1 := getData()
 	s := NewSurfer(WithSep("_"))
@@ -67,3 +112,79 @@ func TestMath(t *testing.T) {
 		t.Errorf("result should be %v not %v", Expression_result, result)
 	}
 }
+
+func BenchmarkMathFlatData(b *testing.B) {
+	l1 := getData()
+	s := NewSurfer(WithSep("_"))
+	expr, err := govaluate.NewEvaluableExpression(Expression)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		data, err := s.GetFlatData(l1)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := expr.Evaluate(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMathParameters(b *testing.B) {
+	l1 := getData()
+	s := NewSurfer(WithSep("_"))
+	expr, err := govaluate.NewEvaluableExpression(Expression)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := expr.Eval(s.Parameters(l1)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGetFlatDataOrdered(t *testing.T) {
+	l1 := getData()
+	s := NewSurfer()
+	kvs, err := s.GetFlatDataOrdered(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"Alfa", "Gamma.Ypsilon", "Gamma.Omega", "Zeta.zeta1", "Zeta.zeta2"}
+	if len(kvs) != len(expected) {
+		t.Fatalf("expected %v fields not %v", len(expected), len(kvs))
+	}
+	for i, kv := range kvs {
+		if kv.Key != expected[i] {
+			t.Errorf("field %v must be %v not %v", i, expected[i], kv.Key)
+		}
+		ok, err := Compare(kv.Value, Vars[kv.Key])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Errorf("variable %v got %v instead of %v", kv.Key, kv.Value, Vars[kv.Key])
+		}
+	}
+}
+
+func TestGetFlatDataOrderedWithKeyOrder(t *testing.T) {
+	s := NewSurfer(WithKeyOrder(func(a string, b string) bool {
+		return a > b
+	}))
+	data := map[string]interface{}{"a": 1, "c": 3, "b": map[string]int{"y": 2, "z": 1}}
+	for run := 0; run < 10; run++ {
+		kvs, err := s.GetFlatDataOrdered(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"c", "b.z", "b.y", "a"}
+		for i, kv := range kvs {
+			if kv.Key != expected[i] {
+				t.Fatalf("field %v must be %v not %v", i, expected[i], kv.Key)
+			}
+		}
+	}
+}
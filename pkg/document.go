@@ -0,0 +1,22 @@
+// document.go defines the decoding of JSON and YAML documents into generic data structures
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// DecodeDocument unmarshals a JSON or a YAML document into maps of string keys, lists and primitive values.
+// Documents starting with { or [ are decoded as JSON, the other ones as YAML as FlattenYAML does,
+// so the numbers of JSON documents are float64 while the integers of YAML documents are int.
+func DecodeDocument(data []byte) (interface{}, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		var doc interface{}
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	}
+	return decodeYAML(data)
+}
@@ -0,0 +1,197 @@
+package pkg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	YY = `
+gamma:
+  omega: test2
+  ypsilon: 10
+alfa: 1.5
+orders:
+  - total: 150
+    paid: true
+  - total: 50
+    paid: false
+codes:
+  1: one
+  true: ok
+`
+	TT = `
+alfa = 1.5
+
+[gamma]
+omega = "test2"
+ypsilon = 10
+since = 2021-10-01T10:00:00Z
+
+[[orders]]
+total = 150
+paid = true
+
+[[orders]]
+total = 50
+paid = false
+`
+)
+
+func TestFlattenYAML(t *testing.T) {
+	s := NewSurfer()
+	flat, err := s.FlattenYAML([]byte(YY))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"gamma.omega":    "test2",
+		"gamma.ypsilon":  10,
+		"alfa":           1.5,
+		"orders.0.total": 150,
+		"orders.0.paid":  true,
+		"orders.1.total": 50,
+		"orders.1.paid":  false,
+		"codes.1":        "one",
+		"codes.true":     "ok",
+	}
+	if len(flat) != len(expected) {
+		t.Errorf("expected %v fields not %v: %v", len(expected), len(flat), flat)
+	}
+	for k, v := range expected {
+		if flat[k] != v {
+			t.Errorf("key %v must be %v not %v", k, v, flat[k])
+		}
+	}
+}
+
+func TestFlattenTOML(t *testing.T) {
+	s := NewSurfer(WithSep("_"))
+	flat, err := s.FlattenTOML([]byte(TT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"gamma_omega":    "test2",
+		"gamma_ypsilon":  int64(10),
+		"gamma_since":    "2021-10-01T10:00:00Z",
+		"alfa":           1.5,
+		"orders_0_total": int64(150),
+		"orders_0_paid":  true,
+		"orders_1_total": int64(50),
+		"orders_1_paid":  false,
+	}
+	if len(flat) != len(expected) {
+		t.Errorf("expected %v fields not %v: %v", len(expected), len(flat), flat)
+	}
+	for k, v := range expected {
+		if flat[k] != v {
+			t.Errorf("key %v must be %v not %v", k, v, flat[k])
+		}
+	}
+}
+
+func TestSamePathsAcrossFormats(t *testing.T) {
+	s := NewSurfer()
+	for _, path := range []string{"gamma.omega", "orders.1.total", "orders.0.paid"} {
+		j, err := s.GetFromJSON(path, []byte(JJ_nested))
+		if err != nil {
+			t.Fatal(err)
+		}
+		y, err := s.GetFromYAML(path, []byte(YY))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt, err := s.GetFromTOML(path, []byte(TT))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range []interface{}{y, tt} {
+			if toString(v) != toString(j) {
+				t.Errorf("path %v got %v instead of %v", path, v, j)
+			}
+		}
+	}
+}
+
+func TestGettersOnDecodedYAML(t *testing.T) {
+	var doc interface{}
+	if err := yaml.Unmarshal([]byte(YY), &doc); err != nil {
+		t.Fatal(err)
+	}
+	s := NewSurfer()
+	omega, err := s.GetString("gamma.omega", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if omega != "test2" {
+		t.Errorf("gamma.omega must be test2 not %v", omega)
+	}
+	total, err := s.GetFloat64("orders.0.total", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 150.0 {
+		t.Errorf("orders.0.total must be 150 not %v", total)
+	}
+	one, err := s.GetString("codes.1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if one != "one" {
+		t.Errorf("codes.1 must be one not %v", one)
+	}
+	if _, err := s.GetFromYAML("orders.2.total", []byte(YY)); err == nil {
+		t.Error("orders.2 does not exist")
+	}
+}
+
+func TestDecodeDocument(t *testing.T) {
+	s := NewSurfer()
+	fromYAML, err := DecodeDocument([]byte(YY))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := json.Marshal(fromYAML); err != nil {
+		t.Errorf("decoded YAML must be encodable as JSON: %v", err)
+	}
+	fromJSON, err := DecodeDocument([]byte(`  {"gamma": {"omega": "test2"}, "codes": {"1": "one"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range []interface{}{fromYAML, fromJSON} {
+		omega, err := s.GetString("gamma.omega", doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if omega != "test2" {
+			t.Errorf("gamma.omega must be test2 not %v", omega)
+		}
+		one, err := s.GetString("codes.1", doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if one != "one" {
+			t.Errorf("codes.1 must be one not %v", one)
+		}
+	}
+	total, err := s.Query("orders.0.total", fromYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(total) != 1 || total[0].Value != 150 {
+		t.Errorf("orders.0.total must be the int 150 as FlattenYAML returns not %v", total)
+	}
+	flat, err := s.FlattenYAML([]byte(YY))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flat["orders.0.total"] != total[0].Value {
+		t.Errorf("DecodeDocument and FlattenYAML must return the same value not %v and %v", total[0].Value, flat["orders.0.total"])
+	}
+	if _, err := DecodeDocument([]byte(`{"gamma": `)); err == nil {
+		t.Error("truncated JSON must fail")
+	}
+}
@@ -0,0 +1,43 @@
+// errors.go defines the errors returned while surfing the data, which can be tested by errors.Is
+package pkg
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMissingField is returned when a field of the name does not exist
+	ErrMissingField = errors.New("missing field")
+	// ErrNilOnPath is returned when a nil pointer, map, list or interface stops the surfing before the field
+	ErrNilOnPath = errors.New("nil on path")
+	// ErrWrongType is returned when a field does not have the type required to read it or to surf through it
+	ErrWrongType = errors.New("wrong type")
+	// ErrInvalidPath is returned when a name is malformed
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrConflict is returned when merging two data structures with different values for the same field
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidExpression is returned when an expression is malformed
+	ErrInvalidExpression = errors.New("invalid expression")
+	// ErrRedacted is returned when a secret field is read by a getter whose type cannot hold its masked value
+	ErrRedacted = errors.New("redacted")
+)
+
+// surfError is an error with a detailed message, whose kind is one of the errors above
+type surfError struct {
+	kind error
+	msg  string
+}
+
+func (e *surfError) Error() string {
+	return e.msg
+}
+
+func (e *surfError) Unwrap() error {
+	return e.kind
+}
+
+// surfErrorf returns an error of the given kind with a formatted message
+func surfErrorf(kind error, format string, a ...interface{}) error {
+	return &surfError{kind: kind, msg: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	s := NewSurfer()
+	data := getData()
+	tests := map[string]error{
+		"Gamma.Nothing":       ErrMissingField,
+		"Zeta.nothing":        ErrMissingField,
+		"Gamma.Epsilon.Delta": ErrNilOnPath,
+		"Alfa.Beta":           ErrWrongType,
+		"Gamma":               ErrWrongType,
+		"Zeta":                ErrWrongType,
+		"Gamma..Omega":        ErrInvalidPath,
+		"Zeta.*":              ErrInvalidPath,
+		"beta":                ErrInvalidPath,
+	}
+	for name, kind := range tests {
+		_, err := s.Get(name, data)
+		if !errors.Is(err, kind) {
+			t.Errorf("field %v must fail with %v not %v", name, kind, err)
+		}
+	}
+	if _, err := s.GetBool(Alfa_name, data); !errors.Is(err, ErrWrongType) {
+		t.Errorf("float64 as bool must fail with %v not %v", ErrWrongType, err)
+	}
+	if _, err := s.GetFloat64("Gamma.Epsilon.Delta", data); !errors.Is(err, ErrNilOnPath) {
+		t.Errorf("getters must keep the kind of the error not %v", err)
+	}
+	value, err := s.Get("Gamma.Omega", &data)
+	if err != nil || value != Omega_value {
+		t.Errorf("Gamma.Omega must be %v not %v (%v)", Omega_value, value, err)
+	}
+}
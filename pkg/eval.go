@@ -0,0 +1,607 @@
+// eval.go implements a small built-in expression engine whose variables are resolved through the Surfer
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// kinds of token recognized by the expression lexer
+const (
+	tok_EOF = iota
+	tok_NUMBER
+	tok_STRING
+	tok_IDENT
+	tok_OP
+	tok_LPAREN
+	tok_RPAREN
+	tok_COMMA
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+// node is an element of the abstract syntax tree of an expression
+type node interface {
+	eval(r resolver) (interface{}, error)
+}
+
+// resolver returns the value of a variable referenced by an expression
+type resolver func(name string) (interface{}, error)
+
+type literalNode struct {
+	value interface{}
+}
+
+type variableNode struct {
+	name string
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+// expression is a parsed expression ready to be evaluated many times
+type expression struct {
+	root node
+	sep  string
+}
+
+// builtin functions available to the expressions
+var functions = map[string]func(args []interface{}) (interface{}, error){
+	"len": func(args []interface{}) (interface{}, error) {
+		if err := checkArgs("len", args, 1); err != nil {
+			return nil, err
+		}
+		return float64(len([]rune(toString(args[0])))), nil
+	},
+	"upper": func(args []interface{}) (interface{}, error) {
+		if err := checkArgs("upper", args, 1); err != nil {
+			return nil, err
+		}
+		return strings.ToUpper(toString(args[0])), nil
+	},
+	"lower": func(args []interface{}) (interface{}, error) {
+		if err := checkArgs("lower", args, 1); err != nil {
+			return nil, err
+		}
+		return strings.ToLower(toString(args[0])), nil
+	},
+	"trim": func(args []interface{}) (interface{}, error) {
+		if err := checkArgs("trim", args, 1); err != nil {
+			return nil, err
+		}
+		return strings.TrimSpace(toString(args[0])), nil
+	},
+	"contains": func(args []interface{}) (interface{}, error) {
+		if err := checkArgs("contains", args, 2); err != nil {
+			return nil, err
+		}
+		return strings.Contains(toString(args[0]), toString(args[1])), nil
+	},
+	"startsWith": func(args []interface{}) (interface{}, error) {
+		if err := checkArgs("startsWith", args, 2); err != nil {
+			return nil, err
+		}
+		return strings.HasPrefix(toString(args[0]), toString(args[1])), nil
+	},
+	"endsWith": func(args []interface{}) (interface{}, error) {
+		if err := checkArgs("endsWith", args, 2); err != nil {
+			return nil, err
+		}
+		return strings.HasSuffix(toString(args[0]), toString(args[1])), nil
+	},
+	"matches": func(args []interface{}) (interface{}, error) {
+		if err := checkArgs("matches", args, 2); err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(toString(args[1]))
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(toString(args[0])), nil
+	},
+	"abs": func(args []interface{}) (interface{}, error) {
+		if err := checkArgs("abs", args, 1); err != nil {
+			return nil, err
+		}
+		f, ok := toFloat64(args[0])
+		if !ok {
+			return nil, fmt.Errorf("abs requires a number not %v", args[0])
+		}
+		return math.Abs(f), nil
+	},
+}
+
+// checkArgs verifies the number of arguments passed to a builtin function
+func checkArgs(name string, args []interface{}, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("function %v requires %v arguments not %v", name, n, len(args))
+	}
+	return nil
+}
+
+// toString returns the string representation of a value
+func toString(i interface{}) string {
+	if s, ok := i.(string); ok {
+		return s
+	}
+	if f, ok := toFloat64(i); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(i)
+}
+
+// toBool returns the boolean value of an operand of a logical operator
+func toBool(op string, i interface{}) (bool, error) {
+	b, ok := i.(bool)
+	if !ok {
+		return false, fmt.Errorf("operator %v requires a bool not %v", op, i)
+	}
+	return b, nil
+}
+
+// tokenize splits an expression into tokens, identifiers may include the separator
+func tokenize(expr string, sep string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(expr)
+	i := 0
+	for i < len(runes) {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				((runes[i] == '+' || runes[i] == '-') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tok_NUMBER, text: string(runes[start:i]), pos: start})
+		case unicode.IsLetter(c) || c == '_' || c == '[':
+			start := i
+			for i < len(runes) {
+				if unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' {
+					i++
+				} else if sep != "" && strings.HasPrefix(string(runes[i:]), sep) {
+					i += len([]rune(sep))
+				} else if runes[i] == '[' && i+1 < len(runes) && runes[i+1] == '"' {
+					// quoted field, e.g. Zeta["v1.2"]
+					j := i + 2
+					for j < len(runes) && runes[j] != '"' {
+						if runes[j] == '\\' {
+							j++
+						}
+						j++
+					}
+					if j+1 >= len(runes) || runes[j+1] != ']' {
+						return nil, fmt.Errorf("unterminated quoted field at position %v", i)
+					}
+					i = j + 2
+				} else {
+					break
+				}
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected character %q at position %v", c, i)
+			}
+			tokens = append(tokens, token{kind: tok_IDENT, text: string(runes[start:i]), pos: start})
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			var sb strings.Builder
+			for i < len(runes) && runes[i] != c {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %v", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tok_STRING, text: sb.String(), pos: start})
+		case c == '(':
+			tokens = append(tokens, token{kind: tok_LPAREN, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tok_RPAREN, text: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tok_COMMA, text: ",", pos: i})
+			i++
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "+", "-", "*", "/", "%", "<", ">", "!"} {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %v", c, i)
+			}
+			tokens = append(tokens, token{kind: tok_OP, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	tokens = append(tokens, token{kind: tok_EOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// parser is a recursive descent parser, from the lowest to the highest precedence:
+// ||, &&, comparison, additive, multiplicative, unary, primary
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tok_EOF {
+		p.pos++
+	}
+	return t
+}
+
+// binary parses a left associative sequence of the given operators
+func (p *parser) binary(ops []string, operand func() (node, error)) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tok_OP || !containsString(ops, t.text) {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) or() (node, error) {
+	return p.binary([]string{"||"}, p.and)
+}
+
+func (p *parser) and() (node, error) {
+	return p.binary([]string{"&&"}, p.comparison)
+}
+
+func (p *parser) comparison() (node, error) {
+	return p.binary([]string{"==", "!=", "<", "<=", ">", ">="}, p.additive)
+}
+
+func (p *parser) additive() (node, error) {
+	return p.binary([]string{"+", "-"}, p.multiplicative)
+}
+
+func (p *parser) multiplicative() (node, error) {
+	return p.binary([]string{"*", "/", "%"}, p.unary)
+}
+
+func (p *parser) unary() (node, error) {
+	t := p.peek()
+	if t.kind == tok_OP && (t.text == "!" || t.text == "-") {
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: t.text, operand: operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tok_NUMBER:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %v at position %v", t.text, t.pos)
+		}
+		return literalNode{value: f}, nil
+	case tok_STRING:
+		return literalNode{value: t.text}, nil
+	case tok_IDENT:
+		switch t.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		}
+		if p.peek().kind != tok_LPAREN {
+			return variableNode{name: t.text}, nil
+		}
+		p.next()
+		if _, ok := functions[t.text]; !ok {
+			return nil, fmt.Errorf("unknown function %v at position %v", t.text, t.pos)
+		}
+		args := []node{}
+		if p.peek().kind == tok_RPAREN {
+			p.next()
+			return callNode{name: t.text, args: args}, nil
+		}
+		for {
+			arg, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			sep := p.next()
+			if sep.kind == tok_RPAREN {
+				return callNode{name: t.text, args: args}, nil
+			}
+			if sep.kind != tok_COMMA {
+				return nil, fmt.Errorf("expected , or ) at position %v", sep.pos)
+			}
+		}
+	case tok_LPAREN:
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tok_RPAREN {
+			return nil, fmt.Errorf("missing ) for ( at position %v", t.pos)
+		}
+		return n, nil
+	case tok_EOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %v at position %v", t.text, t.pos)
+	}
+}
+
+// compile parses an expression using the separator of the Surfer for the variables' names,
+// the syntax errors are of kind ErrInvalidExpression
+func (s Surfer) compile(expr string) (*expression, error) {
+	tokens, err := tokenize(expr, s.sep)
+	if err != nil {
+		return nil, surfErrorf(ErrInvalidExpression, "%v", err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.or()
+	if err != nil {
+		return nil, surfErrorf(ErrInvalidExpression, "%v", err)
+	}
+	if t := p.peek(); t.kind != tok_EOF {
+		return nil, surfErrorf(ErrInvalidExpression, "unexpected %v at position %v", t.text, t.pos)
+	}
+	return &expression{root: root, sep: s.sep}, nil
+}
+
+// evaluate computes the expression against the source, every variable is resolved at most once
+func (e *expression) evaluate(source interface{}) (interface{}, error) {
+	cache := map[string]interface{}{}
+	return e.root.eval(func(name string) (interface{}, error) {
+		if v, ok := cache[name]; ok {
+			return v, nil
+		}
+		v, err := getValueOf(name, source, e.sep)
+		if err != nil {
+			return nil, err
+		}
+		cache[name] = v
+		return v, nil
+	})
+}
+
+func (n literalNode) eval(r resolver) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n variableNode) eval(r resolver) (interface{}, error) {
+	v, err := r(n.name)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := toFloat64(v); ok {
+		return f, nil
+	}
+	return v, nil
+}
+
+func (n unaryNode) eval(r resolver) (interface{}, error) {
+	v, err := n.operand.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		b, err := toBool(n.op, v)
+		if err != nil {
+			return nil, err
+		}
+		return !b, nil
+	default:
+		f, ok := toFloat64(v)
+		if !ok {
+			return nil, fmt.Errorf("operator %v requires a number not %v", n.op, v)
+		}
+		return -f, nil
+	}
+}
+
+func (n binaryNode) eval(r resolver) (interface{}, error) {
+	left, err := n.left.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	// logical operators short-circuit, so the right variables are not resolved when useless
+	if n.op == "&&" || n.op == "||" {
+		l, err := toBool(n.op, left)
+		if err != nil {
+			return nil, err
+		}
+		if (n.op == "&&" && !l) || (n.op == "||" && l) {
+			return l, nil
+		}
+		right, err := n.right.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		return toBool(n.op, right)
+	}
+	right, err := n.right.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	lf, lnum := toFloat64(left)
+	rf, rnum := toFloat64(right)
+	switch n.op {
+	case "==", "!=":
+		var eq bool
+		if lnum && rnum {
+			eq = lf == rf
+		} else {
+			eq = reflect.DeepEqual(left, right)
+		}
+		return eq == (n.op == "=="), nil
+	case "<", "<=", ">", ">=":
+		var c int
+		if lnum && rnum {
+			c = compareFloat64(lf, rf)
+		} else {
+			ls, lok := left.(string)
+			rs, rok := right.(string)
+			if !lok || !rok {
+				return nil, fmt.Errorf("operator %v cannot compare %v and %v", n.op, left, right)
+			}
+			c = strings.Compare(ls, rs)
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	case "+":
+		if lnum && rnum {
+			return lf + rf, nil
+		}
+		_, lstr := left.(string)
+		_, rstr := right.(string)
+		if lstr || rstr {
+			return toString(left) + toString(right), nil
+		}
+		return nil, fmt.Errorf("operator + cannot add %v and %v", left, right)
+	default:
+		if !lnum || !rnum {
+			return nil, fmt.Errorf("operator %v requires numbers not %v and %v", n.op, left, right)
+		}
+		switch n.op {
+		case "-":
+			return lf - rf, nil
+		case "*":
+			return lf * rf, nil
+		case "/":
+			if rf == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return lf / rf, nil
+		default:
+			if rf == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return math.Mod(lf, rf), nil
+		}
+	}
+}
+
+func (n callNode) eval(r resolver) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return functions[n.name](args)
+}
+
+// compareFloat64 returns -1, 0 or 1 comparing two numbers
+func compareFloat64(a float64, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// containsString checks if a list of strings contains the given one
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Eval evaluates an expression against the source, resolving variables lazily by their fully qualified names.
+// Supported are arithmetic (+ - * / %), comparisons (== != < <= > >=), boolean logic (&& || !)
+// and the functions len, upper, lower, trim, contains, startsWith, endsWith, matches and abs.
+// Numbers are always returned as float64. Expressions are evaluated on the real values of the secret fields (see WithRedact),
+// but a result computed from them is masked unless it is a bool, e.g. Password == 'x' is returned while upper(Password) is not.
+func (s Surfer) Eval(expr string, source interface{}) (interface{}, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	e, err := s.compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	result, err := e.evaluate(source)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := result.(bool); ok {
+		return result, nil
+	}
+	names := []string{}
+	variables(e.root, &names)
+	for _, name := range names {
+		if fields, err := parsePath(name, s.sep); err == nil && s.secretFields(fields, source) {
+			return s.maskField(result), nil
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	l1 := getData()
+	s := NewSurfer()
+	cases := map[string]interface{}{
+		"Alfa + Gamma.Ypsilon":            Expression_result,
+		"(Alfa + 1) * 2 - Zeta.zeta2 / 2": 3.0,
+		"Gamma.Ypsilon % 3":               1.0,
+		"-Alfa":                           -1.0,
+		"Gamma.Ypsilon > 5 && Gamma.Omega == 'test2'":                                                true,
+		"Alfa > 5 || !(Gamma.Omega != \"test2\")":                                                    true,
+		"upper(Gamma.Omega) + '-' + len(Gamma.Omega)":                                                "TEST2-5",
+		"contains(Gamma.Omega, 'st') && startsWith(Gamma.Omega, 'te') && endsWith(Gamma.Omega, '2')": true,
+		"matches(Gamma.Omega, '^t[a-z]+[0-9]$')":                                                     true,
+		"abs(Alfa - Gamma.Ypsilon)":                                                                  9.0,
+		"Gamma.Omega < 'z'":                                                                          true,
+	}
+	for expr, expected := range cases {
+		result, err := s.Eval(expr, l1)
+		if err != nil {
+			t.Errorf("expression %v failed: %v", expr, err)
+			continue
+		}
+		if result != expected {
+			t.Errorf("expression %v should be %v not %v", expr, expected, result)
+		}
+	}
+}
+
+func TestEvalWithSep(t *testing.T) {
+	l1 := getData()
+	s := NewSurfer(WithSep("_"))
+	result, err := s.Eval(Expression, l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.(float64) != Expression_result {
+		t.Errorf("result should be %v not %v", Expression_result, result)
+	}
+}
+
+func TestEvalShortCircuit(t *testing.T) {
+	l1 := getData()
+	s := NewSurfer()
+	// Gamma.Epsilon.Delta is behind a nil pointer, it must not be resolved
+	result, err := s.Eval("Alfa > 0 || Gamma.Epsilon.Delta > 0", l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != true {
+		t.Errorf("result should be true not %v", result)
+	}
+	if _, err := s.Eval("Alfa > 0 && Gamma.Epsilon.Delta > 0", l1); err == nil {
+		t.Error("resolving a field behind a nil pointer must fail")
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	l1 := getData()
+	s := NewSurfer()
+	// the value tells if the expression is malformed
+	for expr, malformed := range map[string]bool{
+		"Alfa +":          true,
+		"(Alfa + 1":       true,
+		"'unterminated":   true,
+		"unknown(Alfa)":   true,
+		"Alfa / 0":        false,
+		"Gamma.Omega - 1": false,
+		"Alfa && true":    false,
+		"Missing > 1":     false,
+		"Alfa # 2":        true,
+	} {
+		_, err := s.Eval(expr, l1)
+		if err == nil {
+			t.Errorf("expression %v should fail", expr)
+			continue
+		}
+		if errors.Is(err, ErrInvalidExpression) != malformed {
+			t.Errorf("expression %v malformed must be %v: %v", expr, malformed, err)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// export.go defines the encoders of the flat data returned by the Surfer
+package pkg
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// transformations applied to the keys by the encoders
+const (
+	KEY_AS_IS       = 0
+	KEY_UPPER       = 1
+	KEY_LOWER       = 2
+	KEY_UPPER_SNAKE = 3
+	KEY_LOWER_SNAKE = 4
+)
+
+type encoder struct {
+	keyCase int
+}
+
+type EncoderOption func(*encoder)
+
+// WithKeyCase sets the transformation applied to the keys, e.g. KEY_UPPER_SNAKE turns "Gamma.Ypsilon" into "GAMMA_YPSILON"
+func WithKeyCase(keyCase int) EncoderOption {
+	return func(e *encoder) {
+		e.keyCase = keyCase
+	}
+}
+
+func newEncoder(opts ...EncoderOption) *encoder {
+	e := &encoder{
+		keyCase: KEY_AS_IS,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// transformKey applies the case transformation to a fully qualified name, the snake forms replace the separator with "_"
+func transformKey(key string, sep string, keyCase int) string {
+	switch keyCase {
+	case KEY_UPPER:
+		return strings.ToUpper(key)
+	case KEY_LOWER:
+		return strings.ToLower(key)
+	case KEY_UPPER_SNAKE:
+		return strings.ToUpper(snakeKey(key, sep))
+	case KEY_LOWER_SNAKE:
+		return strings.ToLower(snakeKey(key, sep))
+	default:
+		return key
+	}
+}
+
+// snakeKey joins the fields of a fully qualified name with "_"
+func snakeKey(key string, sep string) string {
+	fields, err := parsePath(key, sep)
+	if err != nil {
+		fields = strings.Split(key, sep)
+	}
+	return strings.Join(fields, "_")
+}
+
+// sortedKeys returns the transformed keys of data sorted, mapped to the original ones
+func (e *encoder) sortedKeys(data map[string]interface{}, sep string, sanitize func(string) string) ([]string, map[string]string, error) {
+	keys := []string{}
+	originals := map[string]string{}
+	for k := range data {
+		key := sanitize(transformKey(k, sep, e.keyCase))
+		if other, ok := originals[key]; ok {
+			return nil, nil, fmt.Errorf("fields %v and %v have the same key %v", other, k, key)
+		}
+		originals[key] = k
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, originals, nil
+}
+
+// formatValue returns the string form of a flat value, nil is an empty string
+func formatValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return toString(v)
+}
+
+// sanitizeEnvName replaces the characters not allowed in the name of an environment variable
+func sanitizeEnvName(key string) string {
+	runes := []rune(key)
+	for i, r := range runes {
+		if !(r == '_' || (r < unicode.MaxASCII && (unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))))) {
+			runes[i] = '_'
+		}
+	}
+	return string(runes)
+}
+
+// escapeDotenv quotes a string value escaping backslashes, quotes, dollars and new lines
+func escapeDotenv(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`)
+	return `"` + r.Replace(v) + `"`
+}
+
+// escapeProperties escapes a key or a value following the rules of Java properties files
+func escapeProperties(v string, isKey bool) string {
+	var sb strings.Builder
+	for i, r := range v {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '=', ':', '#', '!':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case ' ':
+			if isKey || i == 0 {
+				sb.WriteRune('\\')
+			}
+			sb.WriteRune(r)
+		default:
+			if r < 0x20 || r > 0x7e {
+				// properties files are ISO 8859-1
+				if r > 0xffff {
+					r1, r2 := utf16.EncodeRune(r)
+					sb.WriteString(fmt.Sprintf(`\u%04x\u%04x`, r1, r2))
+				} else {
+					sb.WriteString(fmt.Sprintf(`\u%04x`, r))
+				}
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// WriteDotenv writes the flat data as an env file, sorted by key.
+// Characters not allowed in the name of environment variables, like the separator ".", are replaced with "_".
+func (s Surfer) WriteDotenv(w io.Writer, data map[string]interface{}, opts ...EncoderOption) error {
+	e := newEncoder(opts...)
+	keys, originals, err := e.sortedKeys(data, s.sep, sanitizeEnvName)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		v := data[originals[k]]
+		value := formatValue(v)
+		if _, ok := v.(string); ok {
+			value = escapeDotenv(value)
+		}
+		if _, err := fmt.Fprintf(w, "%v=%v\n", k, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteProperties writes the flat data as a Java properties file, sorted by key
+func (s Surfer) WriteProperties(w io.Writer, data map[string]interface{}, opts ...EncoderOption) error {
+	e := newEncoder(opts...)
+	keys, originals, err := e.sortedKeys(data, s.sep, func(k string) string { return k })
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		value := formatValue(data[originals[k]])
+		if _, err := fmt.Fprintf(w, "%v=%v\n", escapeProperties(k, true), escapeProperties(value, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes a list of flat data as CSV, the header is the sorted union of all keys.
+// Fields missing from a row are written as empty values.
+func (s Surfer) WriteCSV(w io.Writer, rows []map[string]interface{}, opts ...EncoderOption) error {
+	e := newEncoder(opts...)
+	union := map[string]interface{}{}
+	for _, row := range rows {
+		for k := range row {
+			union[k] = nil
+		}
+	}
+	keys, originals, err := e.sortedKeys(union, s.sep, func(k string) string { return k })
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(keys); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(keys))
+		for i, k := range keys {
+			record[i] = formatValue(row[originals[k]])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteDotenv(t *testing.T) {
+	s := NewSurfer()
+	data, err := s.GetFlatData(getData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data["Gamma.Quote"] = "say \"hi\" to $USER\n"
+	var buf bytes.Buffer
+	if err := s.WriteDotenv(&buf, data, WithKeyCase(KEY_UPPER_SNAKE)); err != nil {
+		t.Fatal(err)
+	}
+	expected := `ALFA=1
+GAMMA_OMEGA="test2"
+GAMMA_QUOTE="say \"hi\" to \$USER\n"
+GAMMA_YPSILON=10
+ZETA_ZETA1=1
+ZETA_ZETA2=2
+`
+	if buf.String() != expected {
+		t.Errorf("env file must be\n%v\nnot\n%v", expected, buf.String())
+	}
+}
+
+func TestWriteDotenvCollision(t *testing.T) {
+	s := NewSurfer()
+	data := map[string]interface{}{"a.b": 1, "a_b": 2}
+	var buf bytes.Buffer
+	if err := s.WriteDotenv(&buf, data); err == nil {
+		t.Error("a.b and a_b are the same environment variable")
+	}
+}
+
+func TestWriteProperties(t *testing.T) {
+	s := NewSurfer(WithSep("_"))
+	data := map[string]interface{}{
+		"Gamma_Omega": " spaced=value: #1",
+		"Alfa":        1.5,
+		"Key with":    "città\n",
+		"Nil":         nil,
+	}
+	var buf bytes.Buffer
+	if err := s.WriteProperties(&buf, data, WithKeyCase(KEY_LOWER)); err != nil {
+		t.Fatal(err)
+	}
+	expected := `alfa=1.5
+gamma_omega=\ spaced\=value\: \#1
+key\ with=citt\u00e0\n
+nil=
+`
+	if buf.String() != expected {
+		t.Errorf("properties file must be\n%v\nnot\n%v", expected, buf.String())
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	s := NewSurfer()
+	rows, err := s.Select(getOrders(), "Id", "Customer.Name", "Total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows[0]["Customer.Name"] = "Mario, \"Super\""
+	delete(rows[1], "Total")
+	var buf bytes.Buffer
+	if err := s.WriteCSV(&buf, rows[:2], WithKeyCase(KEY_LOWER_SNAKE)); err != nil {
+		t.Fatal(err)
+	}
+	expected := `customer_name,id,total
+"Mario, ""Super""",1,150
+Jean,2,
+`
+	if buf.String() != expected {
+		t.Errorf("csv must be\n%v\nnot\n%v", expected, buf.String())
+	}
+}
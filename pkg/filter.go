@@ -0,0 +1,90 @@
+// filter.go defines the filters restricting the fields visited while flattening the data
+package pkg
+
+// WithInclude restricts GetFlatData, GetFlatDataOrdered, Walk and the flattening of documents (e.g. FlattenJSON) to the fields
+// matching the given patterns or below them, e.g. Billing or Billing.* or **.Total (see WithRedact for the syntax of the patterns).
+// The subtrees which cannot contain a matching field are not visited at all. Malformed patterns are returned by Err
+// and by the methods of the Surfer.
+func WithInclude(patterns ...string) SurferOption {
+	return func(s *Surfer) {
+		s.includePatterns = append(s.includePatterns, patterns...)
+	}
+}
+
+// WithExclude skips the fields matching the given patterns and their subtrees, which are not visited at all,
+// while flattening the data by GetFlatData, GetFlatDataOrdered, Walk and the flattening of documents.
+// Exclusions win over inclusions.
+func WithExclude(patterns ...string) SurferOption {
+	return func(s *Surfer) {
+		s.excludePatterns = append(s.excludePatterns, patterns...)
+	}
+}
+
+// position is a field visited by walk
+type position struct {
+	// name is the fully qualified name of the field
+	name string
+	// fields are the names of the fields of name, tracked only if the Surfer has some patterns to match
+	fields []string
+	// secret is true below a field tagged as secret
+	secret bool
+	// included is true below a field matching WithInclude
+	included bool
+}
+
+// matchPrefix checks if the fields of a name can be the parent of a name matched by the pattern
+func matchPrefix(pattern []string, fields []string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	if len(pattern) == 0 {
+		return false
+	}
+	if pattern[0] == "**" {
+		return true
+	}
+	return matchSegment(pattern[0], fields[0]) && matchPrefix(pattern[1:], fields[1:])
+}
+
+// hasPatterns checks if the names of the fields are needed while walking, to match them against some patterns
+func (s Surfer) hasPatterns() bool {
+	return len(s.redact) > 0 || len(s.include) > 0 || len(s.exclude) > 0
+}
+
+// rootPosition returns the position of the root of the data
+func (s Surfer) rootPosition() position {
+	return position{fields: []string{}, included: len(s.include) == 0}
+}
+
+// enter returns the position of a child of the given one, and false if its subtree must not be visited
+func (s Surfer) enter(parent position, name string, secret bool) (position, bool) {
+	pos := position{
+		name:     s.join(parent.name, name),
+		secret:   parent.secret || secret,
+		included: parent.included,
+	}
+	if !s.hasPatterns() {
+		return pos, true
+	}
+	pos.fields = append(parent.fields[:len(parent.fields):len(parent.fields)], name)
+	for _, pattern := range s.exclude {
+		if matchGlob(pattern, pos.fields) {
+			return pos, false
+		}
+	}
+	if pos.included {
+		return pos, true
+	}
+	for _, pattern := range s.include {
+		if matchGlob(pattern, pos.fields) {
+			pos.included = true
+			return pos, true
+		}
+	}
+	for _, pattern := range s.include {
+		if matchPrefix(pattern, pos.fields) {
+			return pos, true
+		}
+	}
+	return pos, false
+}
@@ -0,0 +1,145 @@
+package pkg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type Billing struct {
+	Total   float64
+	Address map[string]string
+}
+
+type Purchase struct {
+	Id      string
+	Billing Billing
+	Items   []map[string]interface{}
+	Notes   *string
+}
+
+func getPurchase() Purchase {
+	return Purchase{
+		Id: "p1",
+		Billing: Billing{
+			Total:   99.5,
+			Address: map[string]string{"city": "Rome", "zip": "00100"},
+		},
+		Items: []map[string]interface{}{
+			{"sku": "a", "qty": 1, "price": 10.0},
+			{"sku": "b", "qty": 2, "price": 20.0},
+		},
+	}
+}
+
+// visited flattens the data returning the keys of the maps visited, which are recorded by the comparator sorting them
+func visited(t *testing.T, source interface{}, opts ...SurferOption) (map[string]interface{}, map[string]bool) {
+	keys := map[string]bool{}
+	s := NewSurfer(append(opts, WithKeyOrder(func(a string, b string) bool {
+		keys[a] = true
+		keys[b] = true
+		return a < b
+	}))...)
+	flat, err := s.GetFlatData(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return flat, keys
+}
+
+func TestInclude(t *testing.T) {
+	flat, keys := visited(t, getPurchase(), WithInclude("Billing"))
+	expected := map[string]interface{}{
+		"Billing.Total":        99.5,
+		"Billing.Address.city": "Rome",
+		"Billing.Address.zip":  "00100",
+	}
+	if !reflect.DeepEqual(flat, expected) {
+		t.Fatalf("expected %v not %v", expected, flat)
+	}
+	if keys["sku"] || !keys["city"] {
+		t.Fatalf("only the maps of Billing must be visited not %v", keys)
+	}
+}
+
+func TestIncludeGlob(t *testing.T) {
+	flat, _ := visited(t, getPurchase(), WithInclude("Items.*.price", "**.city"))
+	expected := map[string]interface{}{
+		"Items.0.price":        10.0,
+		"Items.1.price":        20.0,
+		"Billing.Address.city": "Rome",
+	}
+	if !reflect.DeepEqual(flat, expected) {
+		t.Fatalf("expected %v not %v", expected, flat)
+	}
+}
+
+func TestExclude(t *testing.T) {
+	flat, keys := visited(t, getPurchase(), WithExclude("Items", "**.zip"))
+	expected := map[string]interface{}{
+		"Id":                   "p1",
+		"Billing.Total":        99.5,
+		"Billing.Address.city": "Rome",
+	}
+	if !reflect.DeepEqual(flat, expected) {
+		t.Fatalf("expected %v not %v", expected, flat)
+	}
+	_, all := visited(t, getPurchase())
+	// the maps of Items are visited without the exclusion, not with it
+	for _, key := range []string{"sku", "qty", "price"} {
+		if keys[key] || !all[key] {
+			t.Fatalf("key %v must be visited only without the exclusion: %v and %v", key, keys, all)
+		}
+	}
+}
+
+func TestIncludeExclude(t *testing.T) {
+	flat, _ := visited(t, getPurchase(), WithInclude("Billing"), WithExclude("Billing/Address"), WithSep("/"))
+	expected := map[string]interface{}{
+		"Billing/Total": 99.5,
+	}
+	if !reflect.DeepEqual(flat, expected) {
+		t.Fatalf("expected %v not %v", expected, flat)
+	}
+}
+
+func TestFilterJSON(t *testing.T) {
+	doc := []byte(`{"id": "p1", "billing": {"total": 99.5, "address": {"city": "Rome", "zip": "00100"}},
+		"customer": {"name": "alice", "address": {"city": "Milan"}},
+		"items": [{"sku": "a", "price": 10.0}, {"sku": "b", "price": 20.0}], "notes": null}`)
+	s := NewSurfer(WithInclude("billing", "items.*.price", "notes"), WithExclude("**.zip"))
+	flat, err := s.FlattenJSON(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"billing.total":        99.5,
+		"billing.address.city": "Rome",
+		"items.0.price":        10.0,
+		"items.1.price":        20.0,
+		"notes":                nil,
+	}
+	if !reflect.DeepEqual(flat, expected) {
+		t.Fatalf("expected %v not %v", expected, flat)
+	}
+}
+
+func TestFilterInvalidPattern(t *testing.T) {
+	for _, s := range []*Surfer{NewSurfer(WithExclude("a[")), NewSurfer(WithInclude("["))} {
+		if !errors.Is(s.Err(), ErrInvalidPath) {
+			t.Fatalf("expected an invalid pattern not %v", s.Err())
+		}
+		if _, err := s.GetFlatData(getPurchase()); !errors.Is(err, ErrInvalidPath) {
+			t.Fatalf("expected an invalid pattern not %v", err)
+		}
+		if _, err := s.FlattenJSON([]byte(`{"a": 1}`)); !errors.Is(err, ErrInvalidPath) {
+			t.Fatalf("expected an invalid pattern not %v", err)
+		}
+		if _, err := s.GetString("Id", getPurchase()); !errors.Is(err, ErrInvalidPath) {
+			t.Fatalf("expected an invalid pattern not %v", err)
+		}
+	}
+	if err := NewSurfer(WithInclude("Billing")).Err(); err != nil {
+		t.Fatal(err)
+	}
+}
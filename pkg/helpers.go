@@ -5,7 +5,7 @@ import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"reflect"
-	"strings"
+	"strconv"
 	"unicode"
 )
 
@@ -25,7 +25,11 @@ const (
 
 // datatype returns the type of an interface using a custom standardization
 func datatype(i interface{}) int {
-	tt := reflect.ValueOf(i).Kind()
+	return kindtype(reflect.ValueOf(i).Kind())
+}
+
+// kindtype returns the custom standardization of a reflect.Kind
+func kindtype(tt reflect.Kind) int {
 	switch tt {
 	case reflect.Ptr:
 		return T_PTR
@@ -52,7 +56,7 @@ func datatype(i interface{}) int {
 
 // checkFieldName checks if a relative field's name is syntattically valid
 func checkFieldName(name string) bool {
-	if len(name) < 0 {
+	if len(name) == 0 {
 		log.Errorf("field's name cannot be null")
 		return false
 	}
@@ -63,7 +67,22 @@ func checkFieldName(name string) bool {
 	return true
 }
 
-// getFieldsFromMap returns the list of keys from a map
+// keyString returns the string form of a map's key, e.g. decoded YAML documents may have keys which are not strings.
+// The null keys (e.g. null or ~ in YAML) are named null.
+func keyString(k reflect.Value) string {
+	if k.Kind() == reflect.Interface && !k.IsNil() {
+		k = k.Elem()
+	}
+	if !k.IsValid() || (k.Kind() == reflect.Interface && k.IsNil()) {
+		return "null"
+	}
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	return fmt.Sprint(k.Interface())
+}
+
+// getFieldsFromMap returns the list of keys from a map, normalised to their string form
 func getFieldsFromMap(m interface{}) []string {
 	fields := []string{}
 	tt := datatype(m)
@@ -71,43 +90,8 @@ func getFieldsFromMap(m interface{}) []string {
 		log.Errorf("skipped fields recognizing because input is not a map but %v", tt)
 		return fields
 	}
-	kk := reflect.TypeOf(m).Key().Kind()
-	if kk != reflect.String {
-		log.Errorf("skipped fields recognizing because map's keys are not string but %v", kk)
-		return fields
-	}
-	vv := reflect.TypeOf(m).Elem().Kind()
-	switch vv {
-	case reflect.Int:
-		for k := range m.(map[string]int) {
-			fields = append(fields, k)
-		}
-	case reflect.Int64:
-		for k := range m.(map[string]int64) {
-			fields = append(fields, k)
-		}
-	case reflect.Float32:
-		for k := range m.(map[string]float32) {
-			fields = append(fields, k)
-		}
-	case reflect.Float64:
-		for k := range m.(map[string]float64) {
-			fields = append(fields, k)
-		}
-	case reflect.Bool:
-		for k := range m.(map[string]bool) {
-			fields = append(fields, k)
-		}
-	case reflect.String:
-		for k := range m.(map[string]string) {
-			fields = append(fields, k)
-		}
-	case reflect.Interface:
-		for k := range m.(map[string]interface{}) {
-			fields = append(fields, k)
-		}
-	default:
-		log.Errorf("skipped fields recognizing because the type of map's values is unsupported: %v", vv)
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		fields = append(fields, keyString(k))
 	}
 	return fields
 }
@@ -116,24 +100,35 @@ func getFieldsFromMap(m interface{}) []string {
 func getValueFromMap(field string, i interface{}) (interface{}, error) {
 	tt := datatype(i)
 	if tt != T_MAP {
-		return nil, fmt.Errorf("skipped fields recognizing because input is not a map but code: %v", tt)
+		return nil, surfErrorf(ErrWrongType, "skipped fields recognizing because input is not a map but code: %v", tt)
 	}
 	m := reflect.ValueOf(i)
 	// in case, to get type of fields -> datatype(reflect.TypeOf(i).Elem())
 	for _, e := range m.MapKeys() {
-		if e.Interface().(string) == field {
+		if keyString(e) == field {
 			return m.MapIndex(e).Interface(), nil
 		}
 	}
-	return reflect.Value{}, fmt.Errorf("map does not contain field %v", field)
+	return reflect.Value{}, surfErrorf(ErrMissingField, "map does not contain field %v", field)
 }
 
 // getValueOf returns the value of a given variable, recursively browsing the given data in the form of an interface{}
 func getValueOf(name string, source interface{}, sep string) (interface{}, error) {
-	fields := strings.Split(name, sep)
+	fields, err := parsePath(name, sep)
+	if err != nil {
+		return nil, err
+	}
+	return getValueOfFields(fields, source)
+}
+
+// getValueOfFields returns the value of a variable given the names of its fields
+func getValueOfFields(fields []string, source interface{}) (interface{}, error) {
+	if len(fields) == 0 {
+		return nil, surfErrorf(ErrInvalidPath, "the name does not reference any field")
+	}
 	field_name := fields[0]
-	if !checkFieldName(field_name) {
-		return nil, fmt.Errorf("field %v is not valid", field_name)
+	if field_name == wildcard {
+		return nil, surfErrorf(ErrInvalidPath, "wildcards are accepted only by queries")
 	}
 	var obj reflect.Value
 	if reflect.ValueOf(source).Kind() == reflect.Ptr {
@@ -144,66 +139,123 @@ func getValueOf(name string, source interface{}, sep string) (interface{}, error
 	}
 	switch obj.Kind() {
 	case reflect.Struct:
+		if !checkFieldName(field_name) {
+			return nil, surfErrorf(ErrInvalidPath, "field %v is not valid", field_name)
+		}
 		f_value := obj.FieldByName(field_name)
 		// f must not be a (struct) zero value
 		if f_value.IsValid() {
 			switch f_value.Kind() {
-			case reflect.Float64, reflect.String, reflect.Bool, reflect.Int, reflect.Float32:
+			case reflect.Float64, reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float32:
 				if len(fields) == 1 {
 					// positive exit: reached the target field
 					return f_value.Interface(), nil
 				} else {
-					return nil, fmt.Errorf("field [%v] is primitive, cannot be a sublevel ", field_name)
+					return nil, surfErrorf(ErrWrongType, "field [%v] is primitive, cannot be a sublevel ", field_name)
 				}
 			case reflect.Struct, reflect.Ptr:
 				if len(fields) == 1 {
 					// positive exit: reached the target field
-					return nil, fmt.Errorf("requested field [%v] points to a struct or a pointer", field_name)
+					return nil, surfErrorf(ErrWrongType, "requested field [%v] points to a struct or a pointer", field_name)
 				} else {
-					if f_value.IsNil() {
-						return nil, fmt.Errorf("surfing stopped by nil field [%v]", field_name)
+					if f_value.Kind() == reflect.Ptr && f_value.IsNil() {
+						return nil, surfErrorf(ErrNilOnPath, "surfing stopped by nil field [%v]", field_name)
 					} else {
 						// going to the sublevel (struct) or getting the object from the pointer
-						return getValueOf(strings.Join(fields[1:], sep), f_value.Interface(), sep)
+						return getValueOfFields(fields[1:], f_value.Interface())
 					}
 				}
-			case reflect.Map:
+			case reflect.Map, reflect.Slice, reflect.Array:
 				if len(fields) == 1 {
 					// positive exit: reached the target field
-					return nil, fmt.Errorf("requested field [%v] points to a map", field_name)
+					return nil, surfErrorf(ErrWrongType, "requested field [%v] points to a map or a list", field_name)
 				} else {
-					if f_value.IsNil() {
-						return nil, fmt.Errorf("surfing stopped by nil field [%v]", field_name)
+					if f_value.Kind() != reflect.Array && f_value.IsNil() {
+						return nil, surfErrorf(ErrNilOnPath, "surfing stopped by nil field [%v]", field_name)
 					} else {
-						// only one level of mapping is supported
-						// map of complex objects is not supported
-						value, err := getValueFromMap(strings.Join(fields[1:], sep), f_value.Interface())
-						if err != nil {
-							return nil, err
-						}
-						return value, err
+						// going to the sublevel (map or list)
+						return getValueOfFields(fields[1:], f_value.Interface())
 					}
 				}
+			case reflect.Interface:
+				if f_value.IsNil() {
+					return nil, surfErrorf(ErrNilOnPath, "surfing stopped by nil field [%v]", field_name)
+				} else if len(fields) == 1 {
+					// positive exit: reached the target field
+					return f_value.Elem().Interface(), nil
+				} else {
+					// going to the sublevel held by the interface
+					return getValueOfFields(fields[1:], f_value.Elem().Interface())
+				}
 			default:
 				// error: field is not a struct or pointer (deep dive not possible)
-				return nil, fmt.Errorf("field %v is a not supported type", field_name)
+				return nil, surfErrorf(ErrWrongType, "field %v is a not supported type", field_name)
 			}
 		}
-		return nil, fmt.Errorf("missing field %v", field_name)
+		return nil, surfErrorf(ErrMissingField, "missing field %v", field_name)
+	case reflect.Map:
+		value, err := getValueFromMap(field_name, obj.Interface())
+		if err != nil || len(fields) == 1 {
+			return value, err
+		}
+		if value == nil {
+			return nil, surfErrorf(ErrNilOnPath, "surfing stopped by nil field [%v]", field_name)
+		}
+		// going to the sublevel of a map of complex objects
+		return getValueOfFields(fields[1:], value)
+	case reflect.Slice, reflect.Array:
+		// elements of a list are referenced by their index
+		index, err := strconv.Atoi(field_name)
+		if err != nil || index < 0 || index >= obj.Len() {
+			return nil, surfErrorf(ErrMissingField, "missing element %v", field_name)
+		}
+		value := obj.Index(index).Interface()
+		if len(fields) == 1 {
+			return value, nil
+		}
+		if value == nil {
+			return nil, surfErrorf(ErrNilOnPath, "surfing stopped by nil element [%v]", field_name)
+		}
+		return getValueOfFields(fields[1:], value)
 	default:
-		return nil, fmt.Errorf("unhandled type of data %v", obj.Kind())
+		return nil, surfErrorf(ErrWrongType, "unhandled type of data %v", obj.Kind())
 	}
 }
 
 // get returns the value of the given field from the given data in the form of an interface{}
 func get(name string, source interface{}, sep string) (interface{}, int, error) {
-	f, err := getValueOf(name, source, sep)
+	fields, err := parsePath(name, sep)
+	if err != nil {
+		return nil, T_NOT_SUPPORTED, err
+	}
+	return getFields(fields, source)
+}
+
+// getFields returns the value of a field, given the names of its fields, in the form of an interface{}
+func getFields(fields []string, source interface{}) (interface{}, int, error) {
+	f, err := getValueOfFields(fields, source)
 	if err != nil {
 		return nil, T_NOT_SUPPORTED, err
 	}
 	t := datatype(f)
 	if t == T_NOT_SUPPORTED {
-		return f, T_NOT_SUPPORTED, fmt.Errorf("type of data not supported: %v", t)
+		return f, T_NOT_SUPPORTED, surfErrorf(ErrWrongType, "type of data not supported: %v", t)
 	}
 	return f, t, nil
-}
\ No newline at end of file
+}
+
+// toFloat64 converts a value of any supported numeric type into a float64
+func toFloat64(i interface{}) (float64, bool) {
+	switch v := i.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0.0, false
+	}
+}
@@ -0,0 +1,166 @@
+// json.go defines the Surfer's methods working directly on JSON documents
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// jsonKey returns the name of the next member of an object, or the index of the next element of an array
+func jsonKey(dec *json.Decoder, delim json.Delim, index int) (string, error) {
+	if delim == '[' {
+		return strconv.Itoa(index), nil
+	}
+	t, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := t.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected object's key %v", t)
+	}
+	return key, nil
+}
+
+// skipJSON consumes the next value of the decoder without materialising it
+func skipJSON(dec *json.Decoder) error {
+	depth := 0
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := t.(json.Delim); ok {
+			if delim == '{' || delim == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// findJSON reads the next value of the decoder looking for the field with the given (remaining) fields
+func (s Surfer) findJSON(dec *json.Decoder, name string, fields []string) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := t.(json.Delim)
+	if !ok {
+		if t == nil {
+			return nil, surfErrorf(ErrNilOnPath, "field %v is reached through a null value", name)
+		}
+		return nil, surfErrorf(ErrWrongType, "field [%v] is primitive, cannot be a sublevel ", fields[0])
+	}
+	for i := 0; dec.More(); i++ {
+		key, err := jsonKey(dec, delim, i)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case key == fields[0] && len(fields) == 1:
+			// positive exit: reached the target field
+			t, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := t.(json.Delim); ok {
+				return nil, surfErrorf(ErrWrongType, "requested field [%v] points to an object or an array", name)
+			}
+			return t, nil
+		case key == fields[0]:
+			// going to the sublevel
+			return s.findJSON(dec, name, fields[1:])
+		default:
+			if err := skipJSON(dec); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, surfErrorf(ErrMissingField, "missing field %v", name)
+}
+
+// flattenJSON reads the next value of the decoder, which is the field at the given position, adding all its primitive values
+// to data. The values of the fields filtered out (see WithInclude) are skipped without being decoded.
+func (s Surfer) flattenJSON(dec *json.Decoder, pos position, data map[string]interface{}) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := t.(json.Delim)
+	if !ok {
+		if pos.name == "" {
+			return surfErrorf(ErrWrongType, "unhandled type of data %T", t)
+		}
+		if pos.included {
+			data[pos.name] = s.redactValue(pos, t)
+		}
+		return nil
+	}
+	for i := 0; dec.More(); i++ {
+		key, err := jsonKey(dec, delim, i)
+		if err != nil {
+			return err
+		}
+		c, ok := s.enter(pos, key, false)
+		if !ok {
+			if err := skipJSON(dec); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.flattenJSON(dec, c, data); err != nil {
+			return err
+		}
+	}
+	// consuming the closing delimiter
+	_, err = dec.Token()
+	return err
+}
+
+// GetFromJSON returns the value of the given field from a JSON document.
+// The document is read as a stream of tokens and only the values on the path of the field are decoded.
+// Elements of arrays are referenced by their index, e.g. "orders.0.total". Secret fields are masked (see WithRedact).
+func (s Surfer) GetFromJSON(name string, data []byte) (interface{}, error) {
+	fields, err := s.fields(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("the name does not reference any field")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	value, err := s.findJSON(dec, name, fields)
+	if err != nil || !s.matchRedact(fields) {
+		return value, err
+	}
+	return s.mask(value), nil
+}
+
+// FlattenJSON returns a map of interface{} including all primitive values of a JSON document,
+// without unmarshaling the whole document first. Elements of arrays are referenced by their index.
+// The filters and the masking of the secret fields are applied as by GetFlatData (see WithInclude and WithRedact).
+func (s Surfer) FlattenJSON(data []byte) (map[string]interface{}, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	flat := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := s.flattenJSON(dec, s.rootPosition(), flat); err != nil {
+		return flat, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("unexpected data after the JSON document")
+		}
+		return flat, err
+	}
+	return flat, nil
+}
@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+)
+
+const (
+	JJ_nested = `
+{
+	"gamma": {"omega": "test2", "ypsilon": 10, "epsilon": null},
+	"alfa": 1.5,
+	"orders": [
+		{"total": 150, "paid": true},
+		{"total": 50, "paid": false}
+	],
+	"zeta": {}
+}
+`
+)
+
+var JJ_nested_flat = map[string]interface{}{
+	"gamma.omega":    "test2",
+	"gamma.ypsilon":  float64(10),
+	"gamma.epsilon":  nil,
+	"alfa":           1.5,
+	"orders.0.total": float64(150),
+	"orders.0.paid":  true,
+	"orders.1.total": float64(50),
+	"orders.1.paid":  false,
+}
+
+func TestFlattenJSON(t *testing.T) {
+	s := NewSurfer()
+	flat, err := s.FlattenJSON([]byte(JJ))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flat) != len(JJ_translate) {
+		t.Errorf("expected %v fields not %v", len(JJ_translate), len(flat))
+	}
+	for k, v := range JJ_translate {
+		if flat[k] != v {
+			t.Errorf("key %v must be %v not %v", k, v, flat[k])
+		}
+	}
+	flat, err = s.FlattenJSON([]byte(JJ_nested))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flat) != len(JJ_nested_flat) {
+		t.Errorf("expected %v fields not %v: %v", len(JJ_nested_flat), len(flat), flat)
+	}
+	for k, v := range JJ_nested_flat {
+		vv, ok := flat[k]
+		if !ok || vv != v {
+			t.Errorf("key %v must be %v not %v", k, v, vv)
+		}
+	}
+}
+
+func TestFlattenJSONWithSep(t *testing.T) {
+	s := NewSurfer(WithSep("_"))
+	flat, err := s.FlattenJSON([]byte(JJ_nested))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flat["orders_1_total"] != float64(50) {
+		t.Errorf("orders_1_total must be 50 not %v", flat["orders_1_total"])
+	}
+}
+
+func TestFlattenJSONErrors(t *testing.T) {
+	s := NewSurfer()
+	for _, doc := range []string{`"scalar"`, `{"a": 1`, `{"a": }`, `{"a": 1} {"b": 2}`, `{"a": 1} x`} {
+		if _, err := s.FlattenJSON([]byte(doc)); err == nil {
+			t.Errorf("document %v should fail", doc)
+		}
+	}
+}
+
+func TestGetFromJSON(t *testing.T) {
+	s := NewSurfer()
+	for k, v := range JJ_nested_flat {
+		vv, err := s.GetFromJSON(k, []byte(JJ_nested))
+		if err != nil {
+			t.Errorf("key %v failed: %v", k, err)
+			continue
+		}
+		if vv != v {
+			t.Errorf("key %v must be %v not %v", k, v, vv)
+		}
+	}
+	failures := map[string]error{
+		"gamma":               ErrWrongType,
+		"orders.2.total":      ErrMissingField,
+		"alfa.beta":           ErrWrongType,
+		"missing":             ErrMissingField,
+		"gamma.omega.x":       ErrWrongType,
+		"gamma.epsilon.delta": ErrNilOnPath,
+	}
+	for name, kind := range failures {
+		if _, err := s.GetFromJSON(name, []byte(JJ_nested)); !errors.Is(err, kind) {
+			t.Errorf("key %v should fail with %v not %v", name, kind, err)
+		}
+	}
+}
+
+func TestGetFromJSONStopsEarly(t *testing.T) {
+	s := NewSurfer()
+	// the document is truncated after the requested field
+	vv, err := s.GetFromJSON("key1", []byte(`{"key1": 1.0, "key2": [1, 2`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vv != 1.0 {
+		t.Errorf("key1 must be 1.0 not %v", vv)
+	}
+}
@@ -0,0 +1,199 @@
+// jsonschema.go defines the generation of JSON Schemas describing the data handled by a Surfer
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// supported layouts of the JSON Schema
+const (
+	SCHEMA_FLAT   = 0
+	SCHEMA_NESTED = 1
+)
+
+// JSON_schema_dialect is the dialect declared by the generated schemas
+const JSON_schema_dialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonType returns the JSON Schema of a leaf field given its custom standardization of the type
+func jsonType(t int) map[string]interface{} {
+	switch t {
+	case T_INT, T_INT64:
+		return map[string]interface{}{"type": "integer"}
+	case T_FLOAT32, T_FLOAT64:
+		return map[string]interface{}{"type": "number"}
+	case T_STRING:
+		return map[string]interface{}{"type": "string"}
+	case T_BOOL:
+		return map[string]interface{}{"type": "boolean"}
+	default:
+		// interfaces may hold anything
+		return map[string]interface{}{}
+	}
+}
+
+// segmentPattern returns the regular expression matching any field within a fully qualified name, plain or quoted
+func segmentPattern(sep string, first bool) string {
+	plain := `[^\[\]"]+`
+	if utf8.RuneCountInString(sep) == 1 {
+		plain = `[^` + regexp.QuoteMeta(sep) + `\[\]"]+`
+	}
+	if !first {
+		plain = regexp.QuoteMeta(sep) + plain
+	}
+	return `(?:` + plain + `|\["(?:[^"\\]|\\.)*"\])`
+}
+
+// pathPattern returns the regular expression matching the fully qualified names of a field with wildcards.
+// If open is true, any further field may follow, as it happens below an interface.
+func pathPattern(fields []string, sep string, open bool) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i, f := range fields {
+		if f == wildcard {
+			sb.WriteString(segmentPattern(sep, i == 0))
+			continue
+		}
+		if i > 0 && !needsQuoting(f, sep) {
+			sb.WriteString(regexp.QuoteMeta(sep))
+		}
+		sb.WriteString(regexp.QuoteMeta(formatSegment(f, sep)))
+	}
+	if open {
+		sb.WriteString(segmentPattern(sep, len(fields) == 0) + "*")
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// flatSchema returns the schema of the flat map returned by GetFlatData, whose keys are the fully qualified names.
+// Fields behind maps, lists and interfaces have unknown names, so they are described by patterns.
+func (s Surfer) flatSchema(t reflect.Type) (map[string]interface{}, error) {
+	fields, err := s.Schema(t)
+	if err != nil {
+		return nil, err
+	}
+	properties := map[string]interface{}{}
+	patterns := map[string]interface{}{}
+	for _, f := range fields {
+		path, err := parsePath(f.Path, s.sep)
+		if err != nil {
+			return nil, err
+		}
+		open := f.GoType.Kind() == reflect.Interface
+		if open || f.Map || f.List {
+			patterns[pathPattern(path, s.sep, open)] = jsonType(f.Type)
+			continue
+		}
+		properties[f.Path] = jsonType(f.Type)
+	}
+	result := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(patterns) > 0 {
+		result["patternProperties"] = patterns
+	}
+	return result, nil
+}
+
+// nestedSchema returns the schema of the given type, following the same rules of GetFlatData about the supported
+// types and the exported fields. It returns false if the type holds no supported field.
+func (s Surfer) nestedSchema(t reflect.Type, stack map[reflect.Type]bool) (map[string]interface{}, bool) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.nestedSchema(t.Elem(), stack)
+	case reflect.Struct:
+		if stack[t] {
+			// recursive types are not expanded, as done by Schema
+			log.Debugf("skipped recursive type %v", t)
+			return nil, false
+		}
+		stack[t] = true
+		defer delete(stack, t)
+		properties := map[string]interface{}{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if child, ok := s.nestedSchema(f.Type, stack); ok {
+				properties[f.Name] = child
+			}
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}, true
+	case reflect.Map:
+		child, ok := s.nestedSchema(t.Elem(), stack)
+		if !ok {
+			return nil, false
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": child,
+		}, true
+	case reflect.Slice, reflect.Array:
+		child, ok := s.nestedSchema(t.Elem(), stack)
+		if !ok {
+			return nil, false
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": child,
+		}, true
+	case reflect.Float64, reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float32, reflect.Interface:
+		return jsonType(kindtype(t.Kind())), true
+	default:
+		log.Debugf("skipped not supported type %v", t)
+		return nil, false
+	}
+}
+
+// JSONSchema returns the JSON Schema of the given data, which may be a value or its reflect.Type.
+// With SCHEMA_FLAT the schema describes the flat map returned by GetFlatData, whose keys are the fully qualified names;
+// with SCHEMA_NESTED it describes the original structure. Both follow the same rules of GetFlatData about names,
+// exported fields and supported types; fields within maps, lists and interfaces are described by patterns.
+func (s Surfer) JSONSchema(v interface{}, layout int) ([]byte, error) {
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	if t == nil {
+		return nil, fmt.Errorf("data cannot be nil")
+	}
+	var schema map[string]interface{}
+	switch layout {
+	case SCHEMA_FLAT:
+		var err error
+		if schema, err = s.flatSchema(t); err != nil {
+			return nil, err
+		}
+	case SCHEMA_NESTED:
+		root := t
+		if root.Kind() == reflect.Ptr {
+			root = root.Elem()
+		}
+		switch root.Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+			schema, _ = s.nestedSchema(root, map[reflect.Type]bool{})
+		default:
+			return nil, fmt.Errorf("unhandled type of data %v", root.Kind())
+		}
+		if schema == nil {
+			return nil, fmt.Errorf("type %v holds no supported field", t)
+		}
+	default:
+		return nil, fmt.Errorf("unknown layout of schema %v", layout)
+	}
+	schema["$schema"] = JSON_schema_dialect
+	return json.Marshal(schema)
+}
@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// checkFlatSchema checks that all the keys of the flat data are described by the flat schema
+func checkFlatSchema(t *testing.T, s *Surfer, source interface{}) {
+	data, err := s.JSONSchema(source, SCHEMA_FLAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := struct {
+		Properties        map[string]interface{}
+		PatternProperties map[string]interface{}
+	}{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	flat, err := s.GetFlatData(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key := range flat {
+		if _, ok := schema.Properties[key]; ok {
+			continue
+		}
+		matched := false
+		for pattern := range schema.PatternProperties {
+			if regexp.MustCompile(pattern).MatchString(key) {
+				matched = true
+			}
+		}
+		if !matched {
+			t.Errorf("key %v is not described by %s", key, data)
+		}
+	}
+}
+
+func TestJSONSchemaFlat(t *testing.T) {
+	s := NewSurfer()
+	data, err := s.JSONSchema(getData(), SCHEMA_FLAT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","additionalProperties":false,` +
+		`"patternProperties":{"^Zeta(?:\\.[^\\.\\[\\]\"]+|\\[\"(?:[^\"\\\\]|\\\\.)*\"\\])$":{"type":"number"}},` +
+		`"properties":{"Alfa":{"type":"number"},"Gamma.Epsilon.Delta":{"type":"integer"},` +
+		`"Gamma.Omega":{"type":"string"},"Gamma.Ypsilon":{"type":"integer"}},"type":"object"}`
+	if string(data) != expected {
+		t.Errorf("schema must be %v not %s", expected, data)
+	}
+	checkFlatSchema(t, s, getData())
+	checkFlatSchema(t, NewSurfer(WithSep("_")), getData())
+	checkFlatSchema(t, s, &Tagged{
+		Name:  "a",
+		Items: []Level3{{Delta: 1}, {Delta: 2}},
+		Data: map[string]interface{}{
+			"v1.2":  "quoted",
+			"inner": map[string]interface{}{"x": 1.0, "y": []interface{}{"a", true}},
+		},
+	})
+}
+
+func TestJSONSchemaNested(t *testing.T) {
+	s := NewSurfer()
+	data, err := s.JSONSchema(reflect.TypeOf(&Tagged{}), SCHEMA_NESTED)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","additionalProperties":false,` +
+		`"properties":{"Data":{"additionalProperties":{},"type":"object"},` +
+		`"Items":{"items":{"additionalProperties":false,"properties":{"Delta":{"type":"integer"}},"type":"object"},"type":"array"},` +
+		`"Name":{"type":"string"}},"type":"object"}`
+	if string(data) != expected {
+		t.Errorf("schema must be %v not %s", expected, data)
+	}
+}
+
+func TestJSONSchemaErrors(t *testing.T) {
+	s := NewSurfer()
+	if _, err := s.JSONSchema(nil, SCHEMA_FLAT); err == nil {
+		t.Errorf("nil data must fail")
+	}
+	if _, err := s.JSONSchema(5, SCHEMA_NESTED); err == nil {
+		t.Errorf("data of primitive type must fail")
+	}
+	if _, err := s.JSONSchema(getData(), 9); err == nil {
+		t.Errorf("unknown layout must fail")
+	}
+}
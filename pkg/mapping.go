@@ -0,0 +1,285 @@
+// mapping.go defines the Surfer's method transforming a data structure into another one by means of a mapping of paths
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MapReport lists the fields left out by a mapping
+type MapReport struct {
+	// Unmapped are the leaf fields of the destination not set by the mapping,
+	// the keys of maps and the indexes of lists are the wildcard * as returned by Schema
+	Unmapped []string
+	// Unused are the leaf fields of the source not read by the mapping
+	Unused []string
+}
+
+// variables appends to names the names of the variables of an expression
+func variables(n node, names *[]string) {
+	switch nn := n.(type) {
+	case variableNode:
+		*names = append(*names, nn.name)
+	case unaryNode:
+		variables(nn.operand, names)
+	case binaryNode:
+		variables(nn.left, names)
+		variables(nn.right, names)
+	case callNode:
+		for _, arg := range nn.args {
+			variables(arg, names)
+		}
+	}
+}
+
+// assign sets a field of the destination converting the value to its type:
+// numbers are converted among them if they fit (integers only from integral values), strings are parsed
+// into numbers and bools, primitive values are formatted into strings and pointers are allocated.
+func assign(dst reflect.Value, value interface{}) error {
+	if value == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(dst.Type()) {
+		dst.Set(v)
+		return nil
+	}
+	str, isString := value.(string)
+	f, isNumber := toFloat64(value)
+	switch dst.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(dst.Type().Elem())
+		if err := assign(elem.Elem(), value); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	case reflect.String:
+		if isNumber || v.Kind() == reflect.Bool {
+			dst.SetString(toString(value))
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if isString {
+			return setFromString(dst, str)
+		}
+		if isNumber {
+			if f != math.Trunc(f) || dst.OverflowInt(int64(f)) {
+				return surfErrorf(ErrWrongType, "value %v does not fit %v", value, dst.Type())
+			}
+			dst.SetInt(int64(f))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if isString {
+			return setFromString(dst, str)
+		}
+		if isNumber {
+			dst.SetFloat(f)
+			return nil
+		}
+	case reflect.Bool:
+		if isString {
+			return setFromString(dst, str)
+		}
+	}
+	return surfErrorf(ErrWrongType, "cannot convert %v (%T) to %v", value, value, dst.Type())
+}
+
+// setFields sets the field with the given names of obj, allocating the nil pointers and maps and growing the slices on the path
+func setFields(obj reflect.Value, fields []string, value interface{}) error {
+	for obj.Kind() == reflect.Ptr {
+		if obj.IsNil() {
+			obj.Set(reflect.New(obj.Type().Elem()))
+		}
+		obj = obj.Elem()
+	}
+	field_name := fields[0]
+	if field_name == wildcard {
+		return surfErrorf(ErrInvalidPath, "wildcards are accepted only by queries")
+	}
+	var target reflect.Value
+	switch obj.Kind() {
+	case reflect.Struct:
+		if !checkFieldName(field_name) {
+			return surfErrorf(ErrInvalidPath, "field %v is not valid", field_name)
+		}
+		target = obj.FieldByName(field_name)
+		if !target.IsValid() {
+			return surfErrorf(ErrMissingField, "missing field %v", field_name)
+		}
+	case reflect.Map:
+		if obj.Type().Key().Kind() != reflect.String {
+			return surfErrorf(ErrWrongType, "keys of map [%v] are not strings", field_name)
+		}
+		if obj.IsNil() {
+			obj.Set(reflect.MakeMap(obj.Type()))
+		}
+		// elements of maps are not addressable, so they are copied, set and stored back
+		key := reflect.ValueOf(field_name).Convert(obj.Type().Key())
+		elem := reflect.New(obj.Type().Elem()).Elem()
+		if current := obj.MapIndex(key); current.IsValid() {
+			elem.Set(current)
+		}
+		var err error
+		if len(fields) == 1 {
+			err = assign(elem, value)
+		} else {
+			err = setFields(elem, fields[1:], value)
+		}
+		if err != nil {
+			return err
+		}
+		obj.SetMapIndex(key, elem)
+		return nil
+	case reflect.Interface:
+		// the held value is copied, set and stored back, a nil interface becomes a map[string]interface{}
+		var inner reflect.Value
+		if obj.IsNil() {
+			inner = reflect.ValueOf(map[string]interface{}{})
+		} else {
+			inner = reflect.New(obj.Elem().Type()).Elem()
+			inner.Set(obj.Elem())
+		}
+		if err := setFields(inner, fields, value); err != nil {
+			return err
+		}
+		obj.Set(inner)
+		return nil
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(field_name)
+		if err != nil || index < 0 {
+			return surfErrorf(ErrInvalidPath, "invalid index %v", field_name)
+		}
+		if index >= obj.Len() {
+			if obj.Kind() == reflect.Array {
+				return surfErrorf(ErrMissingField, "missing element %v", field_name)
+			}
+			grown := reflect.MakeSlice(obj.Type(), index+1, index+1)
+			reflect.Copy(grown, obj)
+			obj.Set(grown)
+		}
+		target = obj.Index(index)
+	default:
+		return surfErrorf(ErrWrongType, "field [%v] cannot be set within %v", field_name, obj.Kind())
+	}
+	if len(fields) == 1 {
+		return assign(target, value)
+	}
+	return setFields(target, fields[1:], value)
+}
+
+// covers checks if the fields of a path are the same or a parent of the other ones, where wildcards match any field
+func covers(path []string, fields []string) bool {
+	if len(path) > len(fields) {
+		return false
+	}
+	for i := range path {
+		if path[i] != fields[i] && path[i] != wildcard && fields[i] != wildcard {
+			return false
+		}
+	}
+	return true
+}
+
+// Map sets the fields of the struct pointed by dst from the source, following the mapping from the destination paths
+// to the source paths, e.g. {"Customer.Name": "name"}. Sources starting with = are expressions as accepted by Eval,
+// e.g. {"Total": "= orders.0.total * 1.2"}. Fields are read by means of the getters and converted to the types of
+// the destination (see assign): nil pointers, maps and slices on the destination paths are allocated.
+// The report lists the destination fields not set and the source fields not read.
+func (s Surfer) Map(src interface{}, dst interface{}, mapping map[string]string) (MapReport, error) {
+	report := MapReport{Unmapped: []string{}, Unused: []string{}}
+	obj, err := getTarget(dst)
+	if err != nil {
+		return report, err
+	}
+	targets := make([]string, 0, len(mapping))
+	for target := range mapping {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	written := [][]string{}
+	read := [][]string{}
+	for _, target := range targets {
+		fields, err := s.fields(target)
+		if err != nil {
+			return report, fmt.Errorf("mapping %v: %w", target, err)
+		}
+		if len(fields) == 0 {
+			return report, fmt.Errorf("mapping %v: the name does not reference any field", target)
+		}
+		var value interface{}
+		source := strings.TrimSpace(mapping[target])
+		if strings.HasPrefix(source, "=") {
+			e, err := s.compile(source[1:])
+			if err != nil {
+				return report, fmt.Errorf("mapping %v: %w", target, err)
+			}
+			names := []string{}
+			variables(e.root, &names)
+			for _, name := range names {
+				if f, err := parsePath(name, s.sep); err == nil {
+					read = append(read, f)
+				}
+			}
+			value, err = e.evaluate(src)
+			if err != nil {
+				return report, fmt.Errorf("mapping %v: %w", target, err)
+			}
+		} else {
+			f, err := s.fields(source)
+			if err != nil {
+				return report, fmt.Errorf("mapping %v: %w", target, err)
+			}
+			read = append(read, f)
+			value, err = getValueOfFields(f, src)
+			if err != nil {
+				return report, fmt.Errorf("mapping %v: %w", target, err)
+			}
+		}
+		if err := setFields(obj, fields, value); err != nil {
+			return report, fmt.Errorf("mapping %v: %w", target, err)
+		}
+		written = append(written, fields)
+	}
+	schema, err := s.Schema(obj.Type())
+	if err != nil {
+		return report, err
+	}
+	for _, info := range schema {
+		leaf, err := parsePath(info.Path, s.sep)
+		if err != nil {
+			return report, err
+		}
+		mapped := false
+		for _, w := range written {
+			mapped = mapped || covers(w, leaf) || (info.GoType.Kind() == reflect.Interface && covers(leaf, w))
+		}
+		if !mapped {
+			report.Unmapped = append(report.Unmapped, info.Path)
+		}
+	}
+	flat, err := s.GetFlatDataOrdered(src)
+	if err != nil {
+		return report, err
+	}
+	for _, kv := range flat {
+		leaf, err := parsePath(kv.Key, s.sep)
+		if err != nil {
+			return report, err
+		}
+		used := false
+		for _, r := range read {
+			used = used || covers(r, leaf)
+		}
+		if !used {
+			report.Unused = append(report.Unused, kv.Key)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type Invoice struct {
+	Number   string
+	Amount   int64
+	Gross    float32
+	Paid     bool
+	Buyer    *Customer
+	Lines    []string
+	Labels   map[string]string
+	Discount *float64
+}
+
+func TestMap(t *testing.T) {
+	s := NewSurfer()
+	order := getOrders()[0]
+	invoice := Invoice{}
+	report, err := s.Map(&order, &invoice, map[string]string{
+		"Number":        "Id",
+		"Amount":        "Total",
+		"Gross":         "= Total * 1.2",
+		"Buyer.Name":    "Customer.Name",
+		"Lines.1":       "Customer.Country",
+		"Labels.origin": "Customer.Country",
+		"Discount":      "= Total / 10",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	discount := 15.0
+	expected := Invoice{
+		Number:   "1",
+		Amount:   150,
+		Gross:    180,
+		Buyer:    &Customer{Name: "Mario"},
+		Lines:    []string{"", "IT"},
+		Labels:   map[string]string{"origin": "IT"},
+		Discount: &discount,
+	}
+	if !reflect.DeepEqual(invoice, expected) {
+		t.Errorf("invoice must be %+v not %+v", expected, invoice)
+	}
+	expectedReport := MapReport{
+		Unmapped: []string{"Paid", "Buyer.Country"},
+		Unused:   []string{},
+	}
+	if !reflect.DeepEqual(report, expectedReport) {
+		t.Errorf("report must be %+v not %+v", expectedReport, report)
+	}
+}
+
+func TestMapDocument(t *testing.T) {
+	s := NewSurfer()
+	doc, err := DecodeDocument([]byte(`{"id": "7", "paid": "true", "total": 99.5, "extra": {"a": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoice := Invoice{}
+	report, err := s.Map(doc, &invoice, map[string]string{"Amount": "id", "Paid": "paid", "Gross": "total"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Amount != 7 || !invoice.Paid || invoice.Gross != 99.5 {
+		t.Errorf("unexpected invoice %+v", invoice)
+	}
+	if !reflect.DeepEqual(report.Unused, []string{"extra.a"}) {
+		t.Errorf("unused fields must be [extra.a] not %v", report.Unused)
+	}
+}
+
+func TestMapErrors(t *testing.T) {
+	s := NewSurfer()
+	order := getOrders()[0]
+	tests := map[string]error{
+		"Nothing":    ErrMissingField,
+		"Amount.Sub": ErrWrongType,
+		"Buyer.*":    ErrInvalidPath,
+		"Paid":       ErrWrongType,
+	}
+	for target, kind := range tests {
+		if _, err := s.Map(&order, &Invoice{}, map[string]string{target: "Total"}); !errors.Is(err, kind) {
+			t.Errorf("mapping to %v must fail with %v not %v", target, kind, err)
+		}
+	}
+	if _, err := s.Map(&order, &Invoice{}, map[string]string{"Amount": "= Total / 7"}); !errors.Is(err, ErrWrongType) {
+		t.Errorf("non integral values must not be set into integers: %v", err)
+	}
+	if _, err := s.Map(&order, &Invoice{}, map[string]string{"Number": "Customer.Nothing"}); !errors.Is(err, ErrMissingField) {
+		t.Errorf("missing source fields must fail: %v", err)
+	}
+	if _, err := s.Map(&order, Invoice{}, map[string]string{"Number": "Id"}); err == nil {
+		t.Errorf("destination must be a pointer")
+	}
+}
@@ -0,0 +1,192 @@
+// merge.go defines the Surfer's method overlaying a data structure onto another one
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// strategies resolving the conflicts of Merge
+const (
+	MERGE_OVERRIDE          = 0
+	MERGE_KEEP_EXISTING     = 1
+	MERGE_ERROR_ON_CONFLICT = 2
+	MERGE_APPEND            = 3
+)
+
+// leaves visits the supported primitive values reachable from obj following the same rules of walk, except for
+// the nil values and the zero fields of structs which are skipped, and the lists which are visited as a whole.
+func (s Surfer) leaves(fields []string, obj reflect.Value, visit func([]string, reflect.Value) error) error {
+	child := func(name string) []string {
+		return append(fields[:len(fields):len(fields)], name)
+	}
+	switch obj.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if obj.IsNil() {
+			return nil
+		}
+		return s.leaves(fields, obj.Elem(), visit)
+	case reflect.Struct:
+		for i := 0; i < obj.NumField(); i++ {
+			f_name := obj.Type().Field(i).Name
+			if !checkFieldName(f_name) || obj.Field(i).IsZero() {
+				continue
+			}
+			if err := s.leaves(child(f_name), obj.Field(i), visit); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		names, keys := s.sortedMapKeys(obj)
+		for _, name := range names {
+			if err := s.leaves(child(name), obj.MapIndex(keys[name]), visit); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if len(fields) > 0 {
+			return visit(fields, obj)
+		}
+		for i := 0; i < obj.Len(); i++ {
+			if err := s.leaves(child(strconv.Itoa(i)), obj.Index(i), visit); err != nil {
+				return err
+			}
+		}
+	case reflect.Float64, reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float32:
+		return visit(fields, obj)
+	}
+	return nil
+}
+
+// existing returns the value of the field with the given names, which is not valid if it is missing or nil
+func existing(obj reflect.Value, fields []string) reflect.Value {
+	for _, f := range fields {
+		obj = deref(obj)
+		if !obj.IsValid() {
+			return obj
+		}
+		c, ok := child(obj, f)
+		if !ok {
+			return reflect.Value{}
+		}
+		obj = c
+	}
+	return deref(obj)
+}
+
+// sameValue checks if two values are equal, numbers are compared whatever their type and lists element by element
+func sameValue(a reflect.Value, b reflect.Value) bool {
+	if a.Kind() == reflect.Slice || a.Kind() == reflect.Array {
+		if (b.Kind() != reflect.Slice && b.Kind() != reflect.Array) || a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !sameValue(deref(a.Index(i)), deref(b.Index(i))) {
+				return false
+			}
+		}
+		return true
+	}
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	fa, okA := toFloat64(a.Interface())
+	fb, okB := toFloat64(b.Interface())
+	if okA && okB {
+		return fa == fb
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// listOf returns the elements of the source list converted to the element type of the destination list, if it exists.
+// If keep is true, the elements of the destination list come first.
+func listOf(current reflect.Value, list reflect.Value, keep bool) (interface{}, error) {
+	if !current.IsValid() || (current.Kind() != reflect.Slice && current.Kind() != reflect.Array) {
+		result := reflect.MakeSlice(reflect.SliceOf(list.Type().Elem()), list.Len(), list.Len())
+		reflect.Copy(result, list)
+		return result.Interface(), nil
+	}
+	t := current.Type()
+	if t.Kind() == reflect.Array {
+		t = reflect.SliceOf(t.Elem())
+	}
+	result := reflect.MakeSlice(t, 0, current.Len()+list.Len())
+	if keep {
+		for i := 0; i < current.Len(); i++ {
+			result = reflect.Append(result, current.Index(i))
+		}
+	}
+	for i := 0; i < list.Len(); i++ {
+		elem := reflect.New(t.Elem()).Elem()
+		if err := assign(elem, list.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+		result = reflect.Append(result, elem)
+	}
+	return result.Interface(), nil
+}
+
+// Merge writes the primitive fields and the lists of src into dst, by their fully qualified names as GetFlatData does.
+// Both can be structs or maps (e.g. map[string]interface{}), dst must be a non nil pointer or map.
+// Missing fields of dst are created where possible, e.g. the keys of maps or the nil pointers.
+// The nil values of src are skipped, as the zero fields of its structs since they cannot be told from the unset ones.
+// The strategy resolves the conflicts with the fields already set (non zero) within dst:
+// MERGE_OVERRIDE writes the src values, MERGE_KEEP_EXISTING keeps the dst values, MERGE_ERROR_ON_CONFLICT
+// fails with ErrConflict if the values differ, leaving dst unchanged, MERGE_APPEND appends the src lists to the dst lists and overrides the other values.
+func (s Surfer) Merge(dst interface{}, src interface{}, strategy int) error {
+	if strategy < MERGE_OVERRIDE || strategy > MERGE_APPEND {
+		return fmt.Errorf("unknown merge strategy %v", strategy)
+	}
+	root := reflect.ValueOf(dst)
+	switch {
+	case root.Kind() == reflect.Ptr && !root.IsNil():
+	case root.Kind() == reflect.Map && !root.IsNil():
+	default:
+		return fmt.Errorf("dst must be a non nil pointer or map not %v", root.Kind())
+	}
+	source, err := getRoot(src)
+	if err != nil {
+		return err
+	}
+	// the leaves are collected first, so a conflict is found before writing any of them
+	type leaf struct {
+		fields []string
+		value  reflect.Value
+	}
+	list := []leaf{}
+	err = s.leaves([]string{}, source, func(fields []string, value reflect.Value) error {
+		list = append(list, leaf{fields: fields, value: value})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if strategy == MERGE_ERROR_ON_CONFLICT {
+		for _, l := range list {
+			current := existing(root, l.fields)
+			if current.IsValid() && !current.IsZero() && !sameValue(current, l.value) {
+				return surfErrorf(ErrConflict, "conflict on field %v: %v and %v", formatPath(l.fields, s.sep), current.Interface(), l.value.Interface())
+			}
+		}
+	}
+	for _, l := range list {
+		current := existing(root, l.fields)
+		if strategy != MERGE_OVERRIDE && strategy != MERGE_APPEND && current.IsValid() && !current.IsZero() {
+			// kept by MERGE_KEEP_EXISTING, or equal for MERGE_ERROR_ON_CONFLICT
+			continue
+		}
+		var v interface{} = l.value.Interface()
+		if l.value.Kind() == reflect.Slice || l.value.Kind() == reflect.Array {
+			merged, err := listOf(current, l.value, strategy == MERGE_APPEND)
+			if err != nil {
+				return fmt.Errorf("field %v: %w", formatPath(l.fields, s.sep), err)
+			}
+			v = merged
+		}
+		if err := setFields(root, l.fields, v); err != nil {
+			return fmt.Errorf("field %v: %w", formatPath(l.fields, s.sep), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type Settings struct {
+	Name    string
+	Port    int
+	Debug   bool
+	Tags    []string
+	Limits  map[string]float64
+	Backend *Customer
+}
+
+func TestMergeStructFromMap(t *testing.T) {
+	s := NewSurfer()
+	settings := Settings{Name: "default", Port: 80, Tags: []string{"a"}}
+	err := s.Merge(&settings, map[string]interface{}{
+		"Port":    8080.0,
+		"Debug":   true,
+		"Tags":    []interface{}{"b"},
+		"Limits":  map[string]interface{}{"cpu": 2.5},
+		"Backend": map[string]interface{}{"Name": "db"},
+		"Name":    nil,
+	}, MERGE_OVERRIDE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Settings{
+		Name:    "default",
+		Port:    8080,
+		Debug:   true,
+		Tags:    []string{"b"},
+		Limits:  map[string]float64{"cpu": 2.5},
+		Backend: &Customer{Name: "db"},
+	}
+	if !reflect.DeepEqual(settings, expected) {
+		t.Fatalf("expected %+v not %+v", expected, settings)
+	}
+}
+
+func TestMergeMapFromStruct(t *testing.T) {
+	s := NewSurfer()
+	dst := map[string]interface{}{
+		"Name":    "default",
+		"Backend": map[string]interface{}{"Country": "Italy"},
+	}
+	err := s.Merge(dst, Settings{Name: "custom", Port: 8080, Backend: &Customer{Name: "db"}}, MERGE_OVERRIDE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"Name":    "custom",
+		"Port":    8080,
+		"Backend": map[string]interface{}{"Name": "db", "Country": "Italy"},
+	}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Fatalf("expected %v not %v", expected, dst)
+	}
+}
+
+func TestMergeStrategies(t *testing.T) {
+	s := NewSurfer()
+	src := map[string]interface{}{
+		"Name": "custom",
+		"Port": 8080,
+		"Tags": []interface{}{"b", "c"},
+	}
+	tests := []struct {
+		strategy int
+		expected Settings
+	}{
+		{MERGE_OVERRIDE, Settings{Name: "custom", Port: 8080, Tags: []string{"b", "c"}}},
+		{MERGE_KEEP_EXISTING, Settings{Name: "default", Port: 8080, Tags: []string{"a"}}},
+		{MERGE_APPEND, Settings{Name: "custom", Port: 8080, Tags: []string{"a", "b", "c"}}},
+	}
+	for _, tt := range tests {
+		settings := Settings{Name: "default", Tags: []string{"a"}}
+		if err := s.Merge(&settings, src, tt.strategy); err != nil {
+			t.Fatalf("strategy %v: %v", tt.strategy, err)
+		}
+		if !reflect.DeepEqual(settings, tt.expected) {
+			t.Fatalf("strategy %v: expected %+v not %+v", tt.strategy, tt.expected, settings)
+		}
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	s := NewSurfer()
+	settings := Settings{Name: "default", Port: 8080}
+	err := s.Merge(&settings, map[string]interface{}{"Port": 8080.0, "Debug": true, "Tags": []interface{}{"a"}}, MERGE_ERROR_ON_CONFLICT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settings.Debug {
+		t.Fatal("expected Debug set because it was not")
+	}
+	err = s.Merge(&settings, map[string]interface{}{"Name": "custom"}, MERGE_ERROR_ON_CONFLICT)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a conflict not %v", err)
+	}
+	// dst is unchanged even by the fields visited before the conflict
+	untouched := Settings{Name: "default"}
+	err = s.Merge(&untouched, map[string]interface{}{"Debug": true, "Name": "custom"}, MERGE_ERROR_ON_CONFLICT)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a conflict not %v", err)
+	}
+	if !reflect.DeepEqual(untouched, Settings{Name: "default"}) {
+		t.Fatalf("expected dst unchanged not %+v", untouched)
+	}
+	settings.Tags = []string{"a"}
+	err = s.Merge(&settings, map[string]interface{}{"Tags": []interface{}{"b"}}, MERGE_ERROR_ON_CONFLICT)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a conflict on the list not %v", err)
+	}
+	if settings.Name != "default" {
+		t.Fatalf("expected Name unchanged not %v", settings.Name)
+	}
+}
+
+func TestMergeFailures(t *testing.T) {
+	s := NewSurfer()
+	if err := s.Merge(Settings{}, map[string]interface{}{}, MERGE_OVERRIDE); err == nil {
+		t.Fatal("expected failure because dst is not a pointer")
+	}
+	var nilMap map[string]interface{}
+	if err := s.Merge(nilMap, map[string]interface{}{"a": 1}, MERGE_OVERRIDE); err == nil {
+		t.Fatal("expected failure because dst is a nil map")
+	}
+	if err := s.Merge(&Settings{}, map[string]interface{}{}, 42); err == nil {
+		t.Fatal("expected failure because of the unknown strategy")
+	}
+	if err := s.Merge(&Settings{}, map[string]interface{}{"Port": "many"}, MERGE_OVERRIDE); err == nil {
+		t.Fatal("expected failure because Port is not a number")
+	}
+	err := s.Merge(&Settings{}, map[string]interface{}{"Missing": 1}, MERGE_OVERRIDE)
+	if !errors.Is(err, ErrMissingField) {
+		t.Fatalf("expected a missing field not %v", err)
+	}
+}
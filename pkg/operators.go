@@ -5,72 +5,125 @@ package pkg
 import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"math"
+	"reflect"
 	"strconv"
 	"strings"
 )
 
+// Get returns the value of the given field, whatever its type.
+// Failures can be classified by errors.Is with ErrMissingField, ErrNilOnPath, ErrWrongType and ErrInvalidPath.
+// Secret fields (see WithRedact) are masked, the secret structs, maps and lists entirely by Redacted_mask.
+func (s Surfer) Get(name string, source interface{}) (interface{}, error) {
+	v, err := s.valueOf(name, source)
+	if err != nil || v == nil || !s.redacted(name, source) {
+		return v, err
+	}
+	return s.maskField(v), nil
+}
+
+// checkRedacted fails with ErrRedacted if the field is secret, for the getters whose type cannot hold a masked value
+func (s Surfer) checkRedacted(name string, source interface{}) error {
+	if s.redacted(name, source) {
+		return surfErrorf(ErrRedacted, "field %v is redacted", name)
+	}
+	return nil
+}
+
 // GetBool returns the float64 value of the given field
 func (s Surfer) GetFloat64(name string, source interface{}) (float64, error) {
-	i, t, err := get(name, source, s.sep)
+	i, t, err := s.lookup(name, source)
 	if err != nil {
 		return 0.0, err
 	}
+	if err := s.checkRedacted(name, source); err != nil {
+		return 0.0, err
+	}
 	switch t {
-	case T_FLOAT64, T_FLOAT32, T_INT64, T_INT:
-		return i.(float64), nil
+	case T_FLOAT64, T_FLOAT32:
+		return reflect.ValueOf(i).Float(), nil
+	case T_INT64, T_INT:
+		return float64(reflect.ValueOf(i).Int()), nil
 	case T_STRING:
-		return strconv.ParseFloat(i.(string), 64)
+		return strconv.ParseFloat(reflect.ValueOf(i).String(), 64)
 	default:
-		return 0.0, fmt.Errorf("variable %v is not float64 but %v", name, t)
+		return 0.0, surfErrorf(ErrWrongType, "variable %v is not float64 but %v", name, t)
 	}
 }
 
 // GetInt64 returns the int64 value of the given field
 func (s Surfer) GetInt64(name string, source interface{}) (int64, error) {
-	i, t, err := get(name, source, s.sep)
+	i, t, err := s.lookup(name, source)
 	if err != nil {
 		return 0.0, err
 	}
+	if err := s.checkRedacted(name, source); err != nil {
+		return 0.0, err
+	}
 	switch t {
 	case T_INT64, T_INT:
-		return i.(int64), nil
+		return reflect.ValueOf(i).Int(), nil
+	case T_FLOAT64, T_FLOAT32:
+		// the numbers of JSON documents are float64, the integral ones are accepted
+		f := reflect.ValueOf(i).Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, surfErrorf(ErrWrongType, "variable %v is not int64 but %v", name, f)
+		}
+		return int64(f), nil
 	case T_STRING:
-		return strconv.ParseInt(i.(string), 0, 64)
+		return strconv.ParseInt(reflect.ValueOf(i).String(), 0, 64)
 	default:
-		return 0.0, fmt.Errorf("variable %v is not int64 but %v", name, t)
+		return 0.0, surfErrorf(ErrWrongType, "variable %v is not int64 but %v", name, t)
 	}
 }
 
-// GetString returns the string value of the given field
+// GetString returns the string value of the given field, masked if it is secret (see WithRedact).
+// Numbers and booleans are formatted, e.g. 5 and true.
 func (s Surfer) GetString(name string, source interface{}) (string, error) {
-	i, t, err := get(name, source, s.sep)
+	i, t, err := s.lookup(name, source)
 	if err != nil {
 		return "", err
 	}
+	var value string
 	switch t {
-	case T_PTR, T_STRUCT, T_MAP:
-		return "", fmt.Errorf("not supported type for string: %v", t)
+	case T_STRING:
+		value = reflect.ValueOf(i).String()
+	case T_INT, T_INT64:
+		value = strconv.FormatInt(reflect.ValueOf(i).Int(), 10)
+	case T_FLOAT32:
+		value = strconv.FormatFloat(reflect.ValueOf(i).Float(), 'f', -1, 32)
+	case T_FLOAT64:
+		value = strconv.FormatFloat(reflect.ValueOf(i).Float(), 'f', -1, 64)
+	case T_BOOL:
+		value = strconv.FormatBool(reflect.ValueOf(i).Bool())
 	default:
-		return i.(string), nil
+		return "", surfErrorf(ErrWrongType, "not supported type for string: %v", t)
+	}
+	if s.redacted(name, source) {
+		return toString(s.mask(value)), nil
 	}
+	return value, nil
 }
 
 // GetBool returns the bool value of the given field
 func (s Surfer) GetBool(name string, source interface{}) (bool, error) {
-	i, t, err := get(name, source, s.sep)
+	i, t, err := s.lookup(name, source)
 	if err != nil {
 		return false, err
 	}
+	if err := s.checkRedacted(name, source); err != nil {
+		return false, err
+	}
 	switch t {
 	case T_BOOL:
-		return i.(bool), nil
+		return reflect.ValueOf(i).Bool(), nil
 	case T_STRING:
-		if strings.ToUpper(i.(string)) == "TRUE" {
+		if strings.ToUpper(reflect.ValueOf(i).String()) == "TRUE" {
 			return true, nil
 		}
 		return false, nil
 	default:
-		return false, fmt.Errorf("variable %v is not bool but %v", name, t)
+		return false, surfErrorf(ErrWrongType, "variable %v is not bool but %v", name, t)
 	}
 }
 
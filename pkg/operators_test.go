@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 )
 
@@ -104,6 +105,56 @@ func TestGetString(t *testing.T) {
 	}
 }
 
+type Counters struct {
+	Count   int
+	Total   int64
+	Ratio   float32
+	Enabled bool
+	Tags    []string
+}
+
+func TestGetConversions(t *testing.T) {
+	c := Counters{Count: 5, Total: 12, Ratio: 1.5, Enabled: true, Tags: []string{"a"}}
+	s := NewSurfer()
+	if v, err := s.GetInt64("Count", c); err != nil || v != 5 {
+		t.Errorf("Count must be 5 not %v (%v)", v, err)
+	}
+	if v, err := s.GetInt64("Total", c); err != nil || v != 12 {
+		t.Errorf("Total must be 12 not %v (%v)", v, err)
+	}
+	if _, err := s.GetInt64("Ratio", c); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Ratio is not integral: %v", err)
+	}
+	if _, err := s.GetInt64("Enabled", c); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Enabled is not int64: %v", err)
+	}
+	if v, err := s.GetInt64("key3", JJ_translate); err != nil || v != 10 {
+		t.Errorf("key3 must be 10 not %v (%v)", v, err)
+	}
+	if v, err := s.GetFloat64("Count", c); err != nil || v != 5.0 {
+		t.Errorf("Count must be 5 not %v (%v)", v, err)
+	}
+	formatted := map[string]string{"Count": "5", "Total": "12", "Ratio": "1.5", "Enabled": "true"}
+	for name, expected := range formatted {
+		if v, err := s.GetString(name, c); err != nil || v != expected {
+			t.Errorf("%v must be %v not %v (%v)", name, expected, v, err)
+		}
+	}
+	if _, err := s.GetString("Tags", c); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Tags is not a string: %v", err)
+	}
+	if v, err := s.GetBool("Enabled", c); err != nil || !v {
+		t.Errorf("Enabled must be true not %v (%v)", v, err)
+	}
+	if _, err := s.GetBool("Count", c); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Count is not bool: %v", err)
+	}
+	masked := NewSurfer(WithRedact("Count"), WithMasker(MaskKeepLast(1)))
+	if v, err := masked.GetString("Count", c); err != nil || v != Redacted_mask {
+		t.Errorf("Count must be masked not %v (%v)", v, err)
+	}
+}
+
 func TestGetFromMap(t *testing.T) {
 	l1 := getData()
 	s := NewSurfer()
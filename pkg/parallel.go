@@ -0,0 +1,179 @@
+// parallel.go defines the flattening of many records by a bounded pool of goroutines
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// FlatRecord is the flat data of a record returned by FlattenStream
+type FlatRecord struct {
+	// Index is the position of the record within the input
+	Index int
+	Data  map[string]interface{}
+	// Err is the failure of flattening the record, it is the last one sent
+	Err error
+}
+
+// job is a record to be flattened by a worker
+type job struct {
+	index  int
+	record interface{}
+}
+
+// recordsOf returns the elements of a list or the values of a map, ordered by their keys as in GetFlatDataOrdered
+func (s Surfer) recordsOf(records interface{}) ([]interface{}, error) {
+	obj, err := getRoot(records)
+	if err != nil {
+		return nil, err
+	}
+	list := []interface{}{}
+	switch obj.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < obj.Len(); i++ {
+			list = append(list, obj.Index(i).Interface())
+		}
+	case reflect.Map:
+		names, keys := s.sortedMapKeys(obj)
+		for _, name := range names {
+			list = append(list, obj.MapIndex(keys[name]).Interface())
+		}
+	default:
+		return nil, fmt.Errorf("records must be a list or a map not %v", obj.Kind())
+	}
+	return list, nil
+}
+
+// FlattenStream flattens the records received from the channel by means of GetFlatData, using the given number of
+// goroutines (runtime.NumCPU if not positive). The flat records are sent in the order of the input, until the records
+// channel is closed. The first failure is sent as the last flat record; when the context is done the flattening
+// stops without any further record. In any case the returned channel is closed, so it can be read by range.
+// After a failure the records channel is no longer read: a producer sending on it must also select on a context
+// which the caller cancels once the returned channel is closed, otherwise the producer blocks forever and leaks.
+func (s Surfer) FlattenStream(ctx context.Context, records <-chan interface{}, workers int) <-chan FlatRecord {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	jobs := make(chan job)
+	results := make(chan FlatRecord)
+	out := make(chan FlatRecord)
+	// window bounds the records flattened but not yet sent, waiting for the previous ones
+	window := make(chan struct{}, 2*workers)
+	go func() {
+		defer close(jobs)
+		for index := 0; ; index++ {
+			var record interface{}
+			var ok bool
+			select {
+			case record, ok = <-records:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- job{index: index, record: record}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				data, err := s.GetFlatData(j.record)
+				if err != nil {
+					err = fmt.Errorf("record %v: %w", j.index, err)
+				}
+				select {
+				case results <- FlatRecord{Index: j.index, Data: data, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	go func() {
+		defer close(out)
+		defer cancel()
+		pending := map[int]FlatRecord{}
+		next := 0
+		for r := range results {
+			pending[r.Index] = r
+			for {
+				head, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				select {
+				case out <- head:
+				case <-ctx.Done():
+					return
+				}
+				if head.Err != nil {
+					return
+				}
+				next++
+				<-window
+			}
+		}
+	}()
+	return out
+}
+
+// FlattenAllContext returns the flat data of each record of a list, or of each value of a map ordered by its keys,
+// flattened by means of FlattenStream. It stops on the first failure and when the context is done, returning its error.
+func (s Surfer) FlattenAllContext(ctx context.Context, records interface{}, workers int) ([]map[string]interface{}, error) {
+	list, err := s.recordsOf(records)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	in := make(chan interface{})
+	go func() {
+		defer close(in)
+		for _, record := range list {
+			select {
+			case in <- record:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	result := make([]map[string]interface{}, 0, len(list))
+	for r := range s.FlattenStream(ctx, in, workers) {
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		result = append(result, r.Data)
+	}
+	if len(result) < len(list) {
+		return nil, ctx.Err()
+	}
+	return result, nil
+}
+
+// FlattenAll returns the flat data of each record of a list, or of each value of a map ordered by its keys,
+// using the given number of goroutines (see FlattenAllContext)
+func (s Surfer) FlattenAll(records interface{}, workers int) ([]map[string]interface{}, error) {
+	return s.FlattenAllContext(context.Background(), records, workers)
+}
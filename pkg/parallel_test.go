@@ -0,0 +1,226 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func getPurchases(n int) []Purchase {
+	purchases := make([]Purchase, n)
+	for i := range purchases {
+		purchases[i] = getPurchase()
+		purchases[i].Id = strconv.Itoa(i)
+		purchases[i].Billing.Total = float64(i)
+	}
+	return purchases
+}
+
+func TestFlattenAll(t *testing.T) {
+	s := NewSurfer()
+	purchases := getPurchases(200)
+	for _, workers := range []int{0, 1, 3, 16} {
+		result, err := s.FlattenAll(purchases, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != len(purchases) {
+			t.Fatalf("expected %v records not %v", len(purchases), len(result))
+		}
+		for i, p := range purchases {
+			expected, err := s.GetFlatData(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result[i], expected) {
+				t.Fatalf("workers %v: record %v must be %v not %v", workers, i, expected, result[i])
+			}
+		}
+	}
+}
+
+func TestFlattenAllMap(t *testing.T) {
+	s := NewSurfer()
+	records := map[string]interface{}{
+		"b": map[string]interface{}{"x": 2.0},
+		"a": map[string]interface{}{"x": 1.0},
+	}
+	result, err := s.FlattenAll(records, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []map[string]interface{}{{"x": 1.0}, {"x": 2.0}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %v not %v", expected, result)
+	}
+}
+
+func TestFlattenAllFailures(t *testing.T) {
+	s := NewSurfer()
+	if _, err := s.FlattenAll(42, 2); err == nil {
+		t.Fatal("expected failure because records are not a list")
+	}
+	records := []interface{}{getPurchase(), getPurchase(), 42, getPurchase()}
+	if _, err := s.FlattenAll(records, 2); err == nil {
+		t.Fatal("expected failure because of the record 2")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.FlattenAllContext(ctx, getPurchases(100), 4); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a canceled context not %v", err)
+	}
+}
+
+func TestFlattenStream(t *testing.T) {
+	s := NewSurfer()
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan interface{})
+	go func() {
+		defer close(in)
+		records := []interface{}{}
+		for _, p := range getPurchases(50) {
+			records = append(records, p)
+		}
+		records = append(records, "not a record", getPurchase())
+		for _, record := range records {
+			// the stream stops reading after the failure, the producer stops when the test cancels the context
+			select {
+			case in <- record:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	count := 0
+	var last FlatRecord
+	for r := range s.FlattenStream(ctx, in, 4) {
+		if r.Index != count {
+			t.Fatalf("expected record %v not %v", count, r.Index)
+		}
+		if r.Err == nil && r.Data["Id"] != strconv.Itoa(r.Index) {
+			t.Fatalf("record %v got %v", r.Index, r.Data["Id"])
+		}
+		last = r
+		count++
+	}
+	cancel()
+	if count != 51 || last.Err == nil {
+		t.Fatalf("expected the stream stopped by the failure of record 50 not %v records (%v)", count, last.Err)
+	}
+}
+
+func TestFlattenStreamCancel(t *testing.T) {
+	s := NewSurfer()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := make(chan interface{})
+	go func() {
+		// the producer never closes the channel, it stops when the context is done
+		for {
+			select {
+			case in <- getPurchase():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	count := 0
+	for range s.FlattenStream(ctx, in, 4) {
+		count++
+		if count == 10 {
+			cancel()
+		}
+	}
+	if count < 10 {
+		t.Fatalf("expected at least 10 records not %v", count)
+	}
+}
+
+func TestFlattenStreamNoLeak(t *testing.T) {
+	s := NewSurfer()
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan interface{})
+	go func() {
+		// the producer never closes the channel, it stops when the caller cancels the context
+		for i := 0; ; i++ {
+			var record interface{} = getPurchase()
+			if i == 20 {
+				record = "not a record"
+			}
+			select {
+			case in <- record:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var last FlatRecord
+	for r := range s.FlattenStream(ctx, in, 4) {
+		last = r
+	}
+	cancel()
+	if last.Err == nil {
+		t.Fatal("expected the stream stopped by the failure of record 20")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %v goroutines not %v", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// the types are new to the cache of the fields of structs, which is filled concurrently
+type cachedA struct {
+	Name  string
+	Value int
+}
+
+type cachedB struct {
+	A      cachedA
+	Hidden string `dataq:"secret"`
+	List   []cachedA
+}
+
+func TestTypeCacheConcurrent(t *testing.T) {
+	s := NewSurfer()
+	record := cachedB{A: cachedA{Name: "a", Value: 1}, Hidden: "h", List: []cachedA{{Name: "b", Value: 2}}}
+	expected := map[string]interface{}{
+		"A.Name":       "a",
+		"A.Value":      1,
+		"Hidden":       Redacted_mask,
+		"List.0.Name":  "b",
+		"List.0.Value": 2,
+	}
+	var wg sync.WaitGroup
+	failures := make(chan string, 32)
+	for g := 0; g < 32; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := s.FlattenAll([]cachedB{record, record}, 4)
+			if err != nil {
+				failures <- err.Error()
+				return
+			}
+			for _, r := range result {
+				if !reflect.DeepEqual(r, expected) {
+					failures <- "unexpected record"
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(failures)
+	for f := range failures {
+		t.Fatal(f)
+	}
+}
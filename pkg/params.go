@@ -0,0 +1,65 @@
+// params.go adapts the Surfer to the Parameters interface of Govaluate
+package pkg
+
+import (
+	"github.com/Knetic/govaluate"
+	"strings"
+)
+
+const (
+	// Govaluate_sep is the separator accepted by Govaluate within the variables' names
+	Govaluate_sep = "_"
+)
+
+// surferParameters resolves the variables of a Govaluate expression on demand
+type surferParameters struct {
+	s      Surfer
+	source interface{}
+	cache  map[string]interface{}
+}
+
+// Parameters returns a govaluate.Parameters which resolves each variable on demand from the source,
+// instead of flattening the whole data structure in advance.
+// If the separator of the Surfer is not allowed by Govaluate, variables may use "_" in its place.
+// The resolved values are memoised, so the returned object is meant for a single evaluation and it is not safe for concurrent use.
+// Secret fields are masked as by Get, so an expression reading them compares their masked values.
+func (s Surfer) Parameters(source interface{}) govaluate.Parameters {
+	return &surferParameters{
+		s:      s,
+		source: source,
+		cache:  map[string]interface{}{},
+	}
+}
+
+// resolve returns the names of the fields and the value of the variable with the given name
+func (p *surferParameters) resolve(name string) ([]string, interface{}, error) {
+	fields, err := parsePath(name, p.s.sep)
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err := getValueOfFields(fields, p.source)
+	return fields, v, err
+}
+
+// Get returns the value of the variable with the given name, masked if it is secret (see WithRedact)
+func (p *surferParameters) Get(name string) (interface{}, error) {
+	if v, ok := p.cache[name]; ok {
+		return v, nil
+	}
+	if p.s.err != nil {
+		return nil, p.s.err
+	}
+	fields, v, err := p.resolve(name)
+	if err != nil && p.s.sep != Govaluate_sep && strings.Contains(name, Govaluate_sep) {
+		// the name may use the separator accepted by Govaluate in place of the one of the Surfer
+		fields, v, err = p.resolve(strings.ReplaceAll(name, Govaluate_sep, p.s.sep))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.s.secretFields(fields, p.source) {
+		v = p.s.maskField(v)
+	}
+	p.cache[name] = v
+	return v, nil
+}
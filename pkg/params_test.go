@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/Knetic/govaluate"
+)
+
+func TestParameters(t *testing.T) {
+	l1 := getData()
+	for _, s := range []*Surfer{NewSurfer(), NewSurfer(WithSep("_"))} {
+		expr, err := govaluate.NewEvaluableExpression(Expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := expr.Eval(s.Parameters(l1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.(float64) != Expression_result {
+			t.Errorf("result should be %v not %v", Expression_result, result)
+		}
+	}
+}
+
+func TestParametersMemoise(t *testing.T) {
+	l1 := getData()
+	s := NewSurfer()
+	expr, err := govaluate.NewEvaluableExpression("Alfa + Alfa * Gamma_Ypsilon - Gamma_Ypsilon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := s.Parameters(l1)
+	if _, err := expr.Eval(params); err != nil {
+		t.Fatal(err)
+	}
+	cache := params.(*surferParameters).cache
+	if len(cache) != 2 {
+		t.Errorf("expected 2 resolved variables not %v", len(cache))
+	}
+}
+
+func TestParametersMissing(t *testing.T) {
+	l1 := getData()
+	s := NewSurfer()
+	expr, err := govaluate.NewEvaluableExpression("Alfa + Gamma_Ypslon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expr.Eval(s.Parameters(l1)); err == nil {
+		t.Error("variable Gamma_Ypslon does not exist")
+	}
+}
@@ -0,0 +1,299 @@
+// path.go defines the grammar of the fully qualified names of the fields
+//
+// A fully qualified name is a list of fields joined by the separator, e.g. Gamma.Omega.
+// A field whose name is empty, "*" or contains the separator, a bracket or a quote is written as a
+// double-quoted Go string within brackets, without separator before it, e.g. Zeta["v1.2"].
+// An unquoted * matches all the fields of a level, e.g. orders.*.total, and it is accepted only by Query.
+// JSON Pointers (RFC 6901) and JSONPath expressions are accepted as alternative syntaxes.
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// supported syntaxes of the fully qualified names
+const (
+	PATH_DOTTED       = 0
+	PATH_JSON_POINTER = 1
+	PATH_JSONPATH     = 2
+)
+
+// PathSyntax returns the syntax with the given name: dotted, pointer (JSON Pointer) or jsonpath, e.g. from a command line flag
+func PathSyntax(name string) (int, error) {
+	switch name {
+	case "dotted":
+		return PATH_DOTTED, nil
+	case "pointer":
+		return PATH_JSON_POINTER, nil
+	case "jsonpath":
+		return PATH_JSONPATH, nil
+	default:
+		return PATH_DOTTED, fmt.Errorf("unknown syntax %v", name)
+	}
+}
+
+// wildcard is the field matching all the fields of a level, it is accepted only by Query
+const wildcard = "\x00*"
+
+// WithPathSyntax sets the syntax of the names accepted by the getters and by Query:
+// PATH_DOTTED (default) e.g. Gamma.Omega, PATH_JSON_POINTER (RFC 6901) e.g. /Gamma/Omega,
+// PATH_JSONPATH e.g. $.Gamma.Omega or $.orders[*].total
+func WithPathSyntax(syntax int) SurferOption {
+	return func(s *Surfer) {
+		s.syntax = syntax
+	}
+}
+
+// needsQuoting checks if the name of a field must be quoted within the fully qualified name
+func needsQuoting(name string, sep string) bool {
+	return name == "" || name == "*" || strings.Contains(name, sep) || strings.ContainsAny(name, `[]"`)
+}
+
+// formatSegment returns the name of a field as it appears within a fully qualified name, quoted if needed
+func formatSegment(name string, sep string) string {
+	if needsQuoting(name, sep) {
+		return "[" + strconv.Quote(name) + "]"
+	}
+	return name
+}
+
+// formatPath joins the names of the fields into a fully qualified name
+func formatPath(fields []string, sep string) string {
+	var sb strings.Builder
+	for i, f := range fields {
+		if needsQuoting(f, sep) {
+			sb.WriteString(formatSegment(f, sep))
+			continue
+		}
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(f)
+	}
+	return sb.String()
+}
+
+// SplitPath splits a fully qualified name written with the given separator into the names of its fields,
+// unquoting the quoted ones, e.g. Zeta["v1.2"] into Zeta and v1.2
+func SplitPath(name string, sep string) ([]string, error) {
+	fields, err := parsePath(name, sep)
+	if err != nil {
+		return nil, surfErrorf(ErrInvalidPath, "%v", err)
+	}
+	for i, f := range fields {
+		if f == wildcard {
+			fields[i] = "*"
+		}
+	}
+	return fields, nil
+}
+
+// JoinPath joins the names of the fields into a fully qualified name written with the given separator,
+// quoting the names which need it, e.g. Zeta and v1.2 into Zeta["v1.2"]
+func JoinPath(fields []string, sep string) string {
+	return formatPath(fields, sep)
+}
+
+// quotedEnd returns the position after the closing quote of the string starting at name[start]
+func quotedEnd(name string, start int) (int, error) {
+	if start >= len(name) || name[start] != '"' {
+		return 0, fmt.Errorf("expected quoted field at position %v of %v", start, name)
+	}
+	for i := start + 1; i < len(name); i++ {
+		switch name[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated quoted field at position %v of %v", start, name)
+}
+
+// parsePath splits a fully qualified name into the names of its fields, rejecting the malformed ones
+func parsePath(name string, sep string) ([]string, error) {
+	if sep == "" {
+		return nil, fmt.Errorf("separator cannot be empty")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("empty field's name")
+	}
+	fields := []string{}
+	i := 0
+	afterSep := true
+	for i < len(name) {
+		switch {
+		case name[i] == '[':
+			end, err := quotedEnd(name, i+1)
+			if err != nil {
+				return nil, err
+			}
+			field, err := strconv.Unquote(name[i+1 : end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted field %v: %v", name[i+1:end], err)
+			}
+			if end >= len(name) || name[end] != ']' {
+				return nil, fmt.Errorf("missing ] at position %v of %v", end, name)
+			}
+			fields = append(fields, field)
+			i = end + 1
+			afterSep = false
+		case strings.HasPrefix(name[i:], sep):
+			if afterSep {
+				return nil, fmt.Errorf("empty field at position %v of %v", i, name)
+			}
+			i += len(sep)
+			afterSep = true
+		default:
+			if !afterSep {
+				return nil, fmt.Errorf("missing separator at position %v of %v", i, name)
+			}
+			end := i
+			for end < len(name) && !strings.HasPrefix(name[end:], sep) && !strings.ContainsRune(`[]"`, rune(name[end])) {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("unexpected %q at position %v of %v", name[i], i, name)
+			}
+			field := name[i:end]
+			if field == "*" {
+				field = wildcard
+			}
+			fields = append(fields, field)
+			i = end
+			afterSep = false
+		}
+	}
+	if afterSep {
+		return nil, fmt.Errorf("name %v ends with the separator", name)
+	}
+	return fields, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into the names of its fields
+func parsePointer(name string) ([]string, error) {
+	if name == "" {
+		return []string{}, nil
+	}
+	if name[0] != '/' {
+		return nil, fmt.Errorf("JSON pointer %v must start with /", name)
+	}
+	fields := strings.Split(name[1:], "/")
+	for i, f := range fields {
+		if strings.Contains(strings.ReplaceAll(strings.ReplaceAll(f, "~0", ""), "~1", ""), "~") {
+			return nil, fmt.Errorf("invalid escape in JSON pointer %v", name)
+		}
+		fields[i] = strings.ReplaceAll(strings.ReplaceAll(f, "~1", "/"), "~0", "~")
+	}
+	return fields, nil
+}
+
+// parseJSONPath splits a JSONPath expression into the names of its fields.
+// Supported are the child operators .name, ['name'] and [index] and the wildcards .* and [*].
+func parseJSONPath(name string) ([]string, error) {
+	if name == "" || name[0] != '$' {
+		return nil, fmt.Errorf("JSONPath %v must start with $", name)
+	}
+	fields := []string{}
+	i := 1
+	for i < len(name) {
+		switch name[i] {
+		case '.':
+			i++
+			if i < len(name) && name[i] == '.' {
+				return nil, fmt.Errorf("recursive descent is not supported: %v", name)
+			}
+			end := i
+			for end < len(name) && name[end] != '.' && name[end] != '[' {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("empty field at position %v of %v", i, name)
+			}
+			field := name[i:end]
+			if field == "*" {
+				field = wildcard
+			}
+			fields = append(fields, field)
+			i = end
+		case '[':
+			end := strings.IndexByte(name[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] at position %v of %v", i, name)
+			}
+			inner := name[i+1 : i+end]
+			switch {
+			case inner == "*":
+				fields = append(fields, wildcard)
+			case len(inner) >= 1 && (inner[0] == '\'' || inner[0] == '"'):
+				// quoted names may contain ], the closing quote is searched first
+				quote := inner[0]
+				j := i + 2
+				var sb strings.Builder
+				for j < len(name) && name[j] != quote {
+					if name[j] == '\\' && j+1 < len(name) {
+						j++
+					}
+					sb.WriteByte(name[j])
+					j++
+				}
+				if j+1 >= len(name) || name[j+1] != ']' {
+					return nil, fmt.Errorf("unterminated quoted field at position %v of %v", i, name)
+				}
+				fields = append(fields, sb.String())
+				end = j + 1 - i
+			default:
+				if _, err := strconv.Atoi(inner); err != nil {
+					return nil, fmt.Errorf("unsupported selector [%v] in %v", inner, name)
+				}
+				fields = append(fields, inner)
+			}
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %v of %v", name[i], i, name)
+		}
+	}
+	return fields, nil
+}
+
+// fields splits a name into the names of its fields, following the path syntax of the Surfer.
+// Malformed names return an ErrInvalidPath.
+func (s Surfer) fields(name string) ([]string, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var fields []string
+	var err error
+	switch s.syntax {
+	case PATH_JSON_POINTER:
+		fields, err = parsePointer(name)
+	case PATH_JSONPATH:
+		fields, err = parseJSONPath(name)
+	default:
+		fields, err = parsePath(name, s.sep)
+	}
+	if err != nil {
+		return nil, surfErrorf(ErrInvalidPath, "%v", err)
+	}
+	return fields, nil
+}
+
+// valueOf returns the value of the field with the given name, following the path syntax of the Surfer
+func (s Surfer) valueOf(name string, source interface{}) (interface{}, error) {
+	fields, err := s.fields(name)
+	if err != nil {
+		return nil, err
+	}
+	return getValueOfFields(fields, source)
+}
+
+// lookup returns the value and the type of the field with the given name, following the path syntax of the Surfer
+func (s Surfer) lookup(name string, source interface{}) (interface{}, int, error) {
+	fields, err := s.fields(name)
+	if err != nil {
+		return nil, T_NOT_SUPPORTED, err
+	}
+	return getFields(fields, source)
+}
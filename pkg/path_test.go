@@ -0,0 +1,152 @@
+package pkg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	cases := map[string][]string{
+		"Alfa":                   {"Alfa"},
+		"Gamma.Omega":            {"Gamma", "Omega"},
+		`Zeta["v1.2"]`:           {"Zeta", "v1.2"},
+		`Zeta["v1.2"].x`:         {"Zeta", "v1.2", "x"},
+		`Zeta["a"]["b"]`:         {"Zeta", "a", "b"},
+		`["v1.2"].Alfa`:          {"v1.2", "Alfa"},
+		`Zeta[""]`:               {"Zeta", ""},
+		`Zeta["quote \" and ]"]`: {"Zeta", `quote " and ]`},
+		"orders.0.total":         {"orders", "0", "total"},
+	}
+	for name, expected := range cases {
+		fields, err := parsePath(name, SEP)
+		if err != nil {
+			t.Errorf("name %v failed: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("name %v must be %v not %v", name, expected, fields)
+		}
+		again, err := parsePath(formatPath(fields, SEP), SEP)
+		if err != nil || !reflect.DeepEqual(again, fields) {
+			t.Errorf("fields %v do not round-trip: %v %v", fields, again, err)
+		}
+	}
+}
+
+func TestParsePathRejected(t *testing.T) {
+	for _, name := range []string{
+		"",
+		".Alfa",
+		"Alfa.",
+		"Gamma..Omega",
+		`Zeta["v1.2"`,
+		`Zeta["v1.2]`,
+		`Zeta[v1.2]`,
+		`Zeta["a"]b`,
+		`Zeta"a"`,
+		`Zeta]`,
+	} {
+		if fields, err := parsePath(name, SEP); err == nil {
+			t.Errorf("name %v should be rejected not parsed as %v", name, fields)
+		}
+	}
+}
+
+func TestFlatDataRoundTrip(t *testing.T) {
+	data := map[string]interface{}{
+		"v1.2": 1,
+		"a_b":  map[string]interface{}{"c.d": "x", "e": true},
+		"":     "empty",
+		"[x]":  2.5,
+	}
+	for _, sep := range []string{".", "_"} {
+		s := NewSurfer(WithSep(sep))
+		flat, err := s.GetFlatData(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(flat) != 5 {
+			t.Errorf("expected 5 fields not %v: %v", len(flat), flat)
+		}
+		for k, v := range flat {
+			vv, err := getValueOf(k, data, sep)
+			if err != nil {
+				t.Errorf("field %v cannot be read back: %v", k, err)
+				continue
+			}
+			if vv != v {
+				t.Errorf("field %v must be %v not %v", k, v, vv)
+			}
+		}
+	}
+}
+
+func TestAmbiguousNames(t *testing.T) {
+	s := NewSurfer()
+	data := map[string]interface{}{
+		"Zeta": map[string]float64{"v1.2": 1.0},
+	}
+	flat, err := s.GetFlatData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := flat[`Zeta["v1.2"]`]; !ok {
+		t.Errorf("key v1.2 must be quoted: %v", flat)
+	}
+	if _, err := s.GetFloat64("Zeta.v1.2", data); err == nil {
+		t.Error("Zeta.v1.2 is ambiguous and must not be resolved")
+	}
+	v, err := s.GetFloat64(`Zeta["v1.2"]`, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 1.0 {
+		t.Errorf("Zeta[\"v1.2\"] must be 1 not %v", v)
+	}
+	result, err := s.Eval(`Zeta["v1.2"] + 1`, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != 2.0 {
+		t.Errorf("result must be 2 not %v", result)
+	}
+	j, err := s.GetFromJSON(`Zeta["v1.2"]`, []byte(`{"Zeta": {"v1": {"2": 3}, "v1.2": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j != 1.0 {
+		t.Errorf("Zeta[\"v1.2\"] must be 1 not %v", j)
+	}
+}
+
+func TestPathSyntax(t *testing.T) {
+	for name, expected := range map[string]int{"dotted": PATH_DOTTED, "pointer": PATH_JSON_POINTER, "jsonpath": PATH_JSONPATH} {
+		syntax, err := PathSyntax(name)
+		if err != nil || syntax != expected {
+			t.Errorf("syntax %v must be %v not %v (%v)", name, expected, syntax, err)
+		}
+	}
+	if _, err := PathSyntax("xpath"); err == nil {
+		t.Error("expected failure because of the unknown syntax")
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	fields, err := SplitPath(`Zeta["v1.2"]/x`, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fields, []string{"Zeta", "v1.2", "x"}) {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	if name := JoinPath(fields, "/"); name != `Zeta/v1.2/x` {
+		t.Fatalf("unexpected name %v", name)
+	}
+	if name := JoinPath(fields, "."); name != `Zeta["v1.2"].x` {
+		t.Fatalf("unexpected name %v", name)
+	}
+	if _, err := SplitPath(`Zeta["v1.2"`, "."); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("expected an invalid path not %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+// query.go defines the Surfer's methods resolving names with wildcards
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// deref returns the object held by pointers and interfaces, which is not valid if any of them is nil
+func deref(obj reflect.Value) reflect.Value {
+	for obj.Kind() == reflect.Ptr || obj.Kind() == reflect.Interface {
+		if obj.IsNil() {
+			return reflect.Value{}
+		}
+		obj = obj.Elem()
+	}
+	return obj
+}
+
+// child returns the field with the given name of a struct, a map or a list
+func child(obj reflect.Value, name string) (reflect.Value, bool) {
+	switch obj.Kind() {
+	case reflect.Struct:
+		if name == "" || !checkFieldName(name) {
+			return reflect.Value{}, false
+		}
+		f_value := obj.FieldByName(name)
+		return f_value, f_value.IsValid()
+	case reflect.Map:
+		for _, k := range obj.MapKeys() {
+			if keyString(k) == name {
+				return obj.MapIndex(k), true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(name)
+		if err == nil && index >= 0 && index < obj.Len() {
+			return obj.Index(index), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// children returns the names and the values of all the fields of a struct, a map or a list, in order
+func (s Surfer) children(obj reflect.Value) ([]string, []reflect.Value) {
+	names := []string{}
+	values := []reflect.Value{}
+	switch obj.Kind() {
+	case reflect.Struct:
+		for i := 0; i < obj.NumField(); i++ {
+			f_name := obj.Type().Field(i).Name
+			if obj.Type().Field(i).PkgPath == "" {
+				names = append(names, f_name)
+				values = append(values, obj.Field(i))
+			}
+		}
+	case reflect.Map:
+		sorted, keys := s.sortedMapKeys(obj)
+		for _, name := range sorted {
+			names = append(names, name)
+			values = append(values, obj.MapIndex(keys[name]))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < obj.Len(); i++ {
+			names = append(names, strconv.Itoa(i))
+			values = append(values, obj.Index(i))
+		}
+	}
+	return names, values
+}
+
+// query appends to result all the values reachable from obj by the given fields, expanding the wildcards.
+// The values are masked if secret is true, i.e. obj is below a field tagged as secret, or they match WithRedact.
+func (s Surfer) query(path []string, fields []string, obj reflect.Value, secret bool, result *[]KV) {
+	if len(fields) == 0 {
+		value := deref(obj)
+		if value.IsValid() {
+			kv := KV{Key: formatPath(path, s.sep), Value: value.Interface()}
+			if secret || s.matchRedact(path) {
+				kv.Value = s.maskField(kv.Value)
+			}
+			*result = append(*result, kv)
+		} else if obj.Kind() == reflect.Interface {
+			// null values of documents are kept
+			*result = append(*result, KV{Key: formatPath(path, s.sep), Value: nil})
+		}
+		return
+	}
+	obj = deref(obj)
+	if !obj.IsValid() {
+		return
+	}
+	if fields[0] != wildcard {
+		if c, ok := child(obj, fields[0]); ok {
+			s.query(append(path[:len(path):len(path)], fields[0]), fields[1:], c, secret || taggedField(obj, fields[0]), result)
+		}
+		return
+	}
+	names, values := s.children(obj)
+	for i := range names {
+		s.query(append(path[:len(path):len(path)], names[i]), fields[1:], values[i], secret || taggedField(obj, names[i]), result)
+	}
+}
+
+// Query returns all the values matching the given name, which may include wildcards following the path syntax of the Surfer,
+// e.g. orders.*.total, /orders/0/total or $.orders[*].total. The keys of the result are the fully qualified names
+// (dotted syntax) of the matching fields, in the same order of Walk. Fields that are not reachable are skipped
+// and the secret ones are masked as by Get.
+func (s Surfer) Query(name string, source interface{}) ([]KV, error) {
+	fields, err := s.fields(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("the name does not reference any field")
+	}
+	result := []KV{}
+	s.query([]string{}, fields, reflect.ValueOf(source), false, &result)
+	return result, nil
+}
@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type Orders struct {
+	Orders []Order
+}
+
+func TestParsePointer(t *testing.T) {
+	cases := map[string][]string{
+		"":             {},
+		"/Gamma/Omega": {"Gamma", "Omega"},
+		"/a~1b/m~0n":   {"a/b", "m~n"},
+		"/orders/0/":   {"orders", "0", ""},
+	}
+	for name, expected := range cases {
+		fields, err := parsePointer(name)
+		if err != nil {
+			t.Errorf("pointer %v failed: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("pointer %v must be %v not %v", name, expected, fields)
+		}
+	}
+	for _, name := range []string{"Gamma/Omega", "/a~2b"} {
+		if _, err := parsePointer(name); err == nil {
+			t.Errorf("pointer %v should be rejected", name)
+		}
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	cases := map[string][]string{
+		"$":                 {},
+		"$.Gamma.Omega":     {"Gamma", "Omega"},
+		"$.orders[*].total": {"orders", wildcard, "total"},
+		"$['v1.2'][0].*":    {"v1.2", "0", wildcard},
+		`$["a]b"].c`:        {"a]b", "c"},
+	}
+	for name, expected := range cases {
+		fields, err := parseJSONPath(name)
+		if err != nil {
+			t.Errorf("JSONPath %v failed: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("JSONPath %v must be %v not %v", name, expected, fields)
+		}
+	}
+	for _, name := range []string{"Gamma", "$..total", "$.orders[?(@.total > 1)]", "$.orders[0:2]", "$.a['b", "$.", "$x"} {
+		if _, err := parseJSONPath(name); err == nil {
+			t.Errorf("JSONPath %v should be rejected", name)
+		}
+	}
+}
+
+func TestGettersWithPathSyntax(t *testing.T) {
+	l1 := getData()
+	pointer := NewSurfer(WithPathSyntax(PATH_JSON_POINTER))
+	omega, err := pointer.GetString("/Gamma/Omega", l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if omega != Omega_value {
+		t.Errorf("Omega must be %v not %v", Omega_value, omega)
+	}
+	jsonpath := NewSurfer(WithPathSyntax(PATH_JSONPATH))
+	zeta, err := jsonpath.GetFloat64("$.Zeta['zeta2']", l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zeta != Zeta_field2_value {
+		t.Errorf("zeta2 must be %v not %v", Zeta_field2_value, zeta)
+	}
+	if _, err := jsonpath.GetFloat64("$.Zeta[*]", l1); err == nil {
+		t.Error("getters must reject wildcards")
+	}
+	if _, err := pointer.GetString("", l1); err == nil {
+		t.Error("the whole document is not a field")
+	}
+	total, err := pointer.GetFromJSON("/orders/1/total", []byte(JJ_nested))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 50.0 {
+		t.Errorf("total must be 50 not %v", total)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	orders := Orders{Orders: getOrders()}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(JJ_nested), &doc); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		s        *Surfer
+		name     string
+		source   interface{}
+		expected []KV
+	}{
+		{NewSurfer(), "Orders.*.Total", orders, []KV{
+			{"Orders.0.Total", 150.0}, {"Orders.1.Total", 200.0}, {"Orders.2.Total", 50.0}, {"Orders.3.Total", 300.0},
+		}},
+		{NewSurfer(WithPathSyntax(PATH_JSONPATH)), "$.Orders[*].Customer.Country", &orders, []KV{
+			{"Orders.0.Customer.Country", "IT"}, {"Orders.1.Customer.Country", "FR"},
+			{"Orders.2.Customer.Country", "IT"}, {"Orders.3.Customer.Country", "IT"},
+		}},
+		{NewSurfer(WithPathSyntax(PATH_JSONPATH)), "$.orders[*].total", doc, []KV{
+			{"orders.0.total", 150.0}, {"orders.1.total", 50.0},
+		}},
+		{NewSurfer(), "gamma.*", doc, []KV{
+			{"gamma.epsilon", nil}, {"gamma.omega", "test2"}, {"gamma.ypsilon", 10.0},
+		}},
+		{NewSurfer(WithPathSyntax(PATH_JSON_POINTER)), "/gamma/omega", doc, []KV{
+			{"gamma.omega", "test2"},
+		}},
+		{NewSurfer(), "Gamma.Epsilon.*", getData(), []KV{}},
+		{NewSurfer(), "*", getData(), []KV{
+			{"Alfa", Alfa_value}, {"Gamma", *getData().Gamma}, {"Zeta", getData().Zeta},
+		}},
+	}
+	for _, c := range cases {
+		result, err := c.s.Query(c.name, c.source)
+		if err != nil {
+			t.Errorf("query %v failed: %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, c.expected) {
+			t.Errorf("query %v must be %v not %v", c.name, c.expected, result)
+		}
+	}
+	if _, err := NewSurfer().Query("Orders..Total", orders); err == nil {
+		t.Error("malformed names must be rejected")
+	}
+}
@@ -0,0 +1,210 @@
+// redact.go defines the masking of the sensitive fields within the flat data and the values returned by the getters
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"reflect"
+	"strings"
+)
+
+const (
+	// Redacted_mask replaces the secret values, entirely or but their last characters
+	Redacted_mask = "********"
+	// Secret_tag is the value of the tag dataq marking a field of a struct as secret, e.g. `dataq:"secret"`
+	Secret_tag = "secret"
+)
+
+// Masker returns the masked form of a secret value
+type Masker func(value interface{}) string
+
+// MaskFull replaces the whole value with Redacted_mask, so even its length is hidden
+func MaskFull() Masker {
+	return func(value interface{}) string {
+		return Redacted_mask
+	}
+}
+
+// MaskKeepLast replaces the value with Redacted_mask followed by its last n characters, e.g. ********1234.
+// Values not longer than n characters are masked entirely.
+func MaskKeepLast(n int) Masker {
+	return func(value interface{}) string {
+		runes := []rune(toString(value))
+		if n <= 0 || len(runes) <= n {
+			return Redacted_mask
+		}
+		return Redacted_mask + string(runes[len(runes)-n:])
+	}
+}
+
+// MaskHash replaces the value with the first 16 hex digits of its SHA-256, e.g. sha256:9f86d081884c7d65,
+// so equal values can be correlated without being revealed. Values with few possible forms (e.g. PINs)
+// can be guessed hashing all of them, so they should be masked by MaskFull.
+func MaskHash() Masker {
+	return func(value interface{}) string {
+		sum := sha256.Sum256([]byte(toString(value)))
+		return "sha256:" + hex.EncodeToString(sum[:])[:16]
+	}
+}
+
+// WithRedact sets the patterns of the fields to be masked by GetFlatData, Walk and the getters, in addition to the
+// fields of structs tagged `dataq:"secret"`. Patterns are names using the separator of the Surfer, whose fields can be
+// * (any field of a level), ** (any number of levels) or globs as accepted by path.Match, e.g. **.Password or Cards.*.Number.
+// A pattern matching a field masks all the fields below it. Malformed patterns are returned by Err and by the methods
+// of the Surfer.
+func WithRedact(patterns ...string) SurferOption {
+	return func(s *Surfer) {
+		s.redactPatterns = append(s.redactPatterns, patterns...)
+	}
+}
+
+// WithMasker sets the masking of the secret values, by default MaskFull
+func WithMasker(m Masker) SurferOption {
+	return func(s *Surfer) {
+		s.masker = m
+	}
+}
+
+// matchSegment checks if the field of a pattern matches the field of a name
+func matchSegment(pattern string, field string) bool {
+	if pattern == wildcard || pattern == field {
+		return true
+	}
+	ok, err := path.Match(pattern, field)
+	return err == nil && ok
+}
+
+// matchGlob checks if the fields of a pattern match all the fields of a name, where ** matches any number of fields
+func matchGlob(pattern []string, fields []string) bool {
+	if len(pattern) == 0 {
+		return len(fields) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(fields); i++ {
+			if matchGlob(pattern[1:], fields[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(fields) == 0 || !matchSegment(pattern[0], fields[0]) {
+		return false
+	}
+	return matchGlob(pattern[1:], fields[1:])
+}
+
+// matchAncestor checks if a pattern matches the fields of a name or one of its parents
+func matchAncestor(pattern []string, fields []string) bool {
+	for i := 1; i <= len(fields); i++ {
+		if matchGlob(pattern, fields[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePatterns splits the patterns into the names of their fields
+func parsePatterns(patterns []string, sep string) ([][]string, error) {
+	result := make([][]string, 0, len(patterns))
+	for _, p := range patterns {
+		fields, err := parsePath(p, sep)
+		if err != nil {
+			return nil, surfErrorf(ErrInvalidPath, "pattern %v: %v", p, err)
+		}
+		result = append(result, fields)
+	}
+	return result, nil
+}
+
+// isSecret checks if a field of a struct is tagged as secret, e.g. `dataq:"secret"` or `dataq:"name,secret"`
+func isSecret(f reflect.StructField) bool {
+	for _, option := range strings.Split(f.Tag.Get("dataq"), ",") {
+		if strings.TrimSpace(option) == Secret_tag {
+			return true
+		}
+	}
+	return false
+}
+
+// taggedField checks if obj is a struct whose field with the given name is tagged as secret
+func taggedField(obj reflect.Value, name string) bool {
+	if obj.Kind() != reflect.Struct {
+		return false
+	}
+	sf, ok := obj.Type().FieldByName(name)
+	return ok && isSecret(sf)
+}
+
+// taggedSecret checks if a field tagged as secret is on the path of the field with the given names
+func taggedSecret(fields []string, obj reflect.Value) bool {
+	for _, f := range fields {
+		obj = deref(obj)
+		if taggedField(obj, f) {
+			return true
+		}
+		c, ok := child(obj, f)
+		if !ok {
+			return false
+		}
+		obj = c
+	}
+	return false
+}
+
+// matchRedact checks if the field with the given names matches one of the patterns of WithRedact
+func (s Surfer) matchRedact(fields []string) bool {
+	for _, pattern := range s.redact {
+		if matchAncestor(pattern, fields) {
+			return true
+		}
+	}
+	return false
+}
+
+// mask returns the masked form of a value, the nil values are kept
+func (s Surfer) mask(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	if s.masker == nil {
+		return MaskFull()(value)
+	}
+	return s.masker(value)
+}
+
+// redactValue returns the value of the field at the given position masked if the field is secret,
+// i.e. it is below a field tagged as secret or it matches the patterns of WithRedact
+func (s Surfer) redactValue(pos position, value interface{}) interface{} {
+	if pos.secret || s.matchRedact(pos.fields) {
+		return s.mask(value)
+	}
+	return value
+}
+
+// maskField returns the masked value of a secret field, the structs, maps and lists entirely by Redacted_mask
+func (s Surfer) maskField(value interface{}) interface{} {
+	switch datatype(value) {
+	case T_INT, T_INT64, T_FLOAT32, T_FLOAT64, T_STRING, T_BOOL:
+		return s.mask(value)
+	default:
+		if value == nil {
+			return nil
+		}
+		return Redacted_mask
+	}
+}
+
+// secretFields checks if the field with the given names of the source must be masked
+func (s Surfer) secretFields(fields []string, source interface{}) bool {
+	return s.matchRedact(fields) || taggedSecret(fields, reflect.ValueOf(source))
+}
+
+// redacted checks if the field with the given name of the source must be masked
+func (s Surfer) redacted(name string, source interface{}) bool {
+	fields, err := s.fields(name)
+	if err != nil {
+		return false
+	}
+	return s.secretFields(fields, source)
+}
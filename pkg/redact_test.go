@@ -0,0 +1,291 @@
+package pkg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+type Card struct {
+	Number string
+	Holder string
+	Pin    int `dataq:"secret"`
+}
+
+type Account struct {
+	User        string
+	Password    string
+	Cards       []Card
+	Credentials *Credentials `dataq:"secret"`
+	Settings    map[string]interface{}
+}
+
+type Credentials struct {
+	Token  string
+	Expiry int64
+}
+
+func getAccount() Account {
+	return Account{
+		User:        "alice",
+		Password:    "hunter2",
+		Cards:       []Card{{Number: "4111111111111111", Holder: "Alice", Pin: 1234}},
+		Credentials: &Credentials{Token: "abc", Expiry: 86400},
+		Settings:    map[string]interface{}{"smtp": map[string]interface{}{"password": "mail", "host": "localhost"}},
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"**.Password", "Password", true},
+		{"**.Password", "a.b.Password", true},
+		{"**.Password", "a.Password.b", false},
+		{"Cards.*.Number", "Cards.0.Number", true},
+		{"Cards.*.Number", "Cards.0.Holder", false},
+		{"**.pass*", "Settings.smtp.password", true},
+		{"Settings.**", "Settings.smtp.host", true},
+		{"User", "Users", false},
+	}
+	for _, tt := range tests {
+		pattern, err := parsePath(tt.pattern, Default_sep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fields, err := parsePath(tt.name, Default_sep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matchGlob(pattern, fields) != tt.match {
+			t.Fatalf("expected %v matching %v with %v", tt.match, tt.name, tt.pattern)
+		}
+	}
+}
+
+func TestRedactFlatData(t *testing.T) {
+	s := NewSurfer(WithRedact("**.Password", "**.password", "Cards.*.Number"), WithMasker(MaskKeepLast(4)))
+	flat, err := s.GetFlatData(getAccount())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"User":                   "alice",
+		"Password":               "********ter2",
+		"Cards.0.Number":         "********1111",
+		"Cards.0.Holder":         "Alice",
+		"Cards.0.Pin":            Redacted_mask,
+		"Credentials.Token":      Redacted_mask,
+		"Credentials.Expiry":     "********6400",
+		"Settings.smtp.password": Redacted_mask,
+		"Settings.smtp.host":     "localhost",
+	}
+	for k, v := range expected {
+		if flat[k] != v {
+			t.Fatalf("expected %v for %v not %v", v, k, flat[k])
+		}
+	}
+	if len(flat) != len(expected) {
+		t.Fatalf("expected %v fields not %v", len(expected), len(flat))
+	}
+}
+
+func TestRedactTagOnly(t *testing.T) {
+	s := NewSurfer()
+	flat, err := s.GetFlatData(getAccount())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flat["Password"] != "hunter2" || flat["Cards.0.Pin"] != Redacted_mask || flat["Credentials.Token"] != Redacted_mask {
+		t.Fatalf("expected only the tagged fields masked not %v", flat)
+	}
+}
+
+func TestRedactHash(t *testing.T) {
+	s := NewSurfer(WithRedact("Password"), WithMasker(MaskHash()))
+	a, err := s.Get("Password", getAccount())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.Get("Password", getAccount())
+	if err != nil {
+		t.Fatal(err)
+	}
+	str, ok := a.(string)
+	if !ok || !strings.HasPrefix(str, "sha256:") || len(str) != len("sha256:")+16 || a != b {
+		t.Fatalf("expected the same hash not %v and %v", a, b)
+	}
+	if strings.Contains(str, "hunter2") {
+		t.Fatalf("hash %v reveals the value", str)
+	}
+}
+
+func TestRedactGetters(t *testing.T) {
+	s := NewSurfer(WithRedact("**.Password", "Settings.smtp"))
+	account := getAccount()
+	if v, err := s.GetString("Password", account); err != nil || v != Redacted_mask {
+		t.Fatalf("expected masked password not %v (%v)", v, err)
+	}
+	if v, err := s.GetString("User", account); err != nil || v != "alice" {
+		t.Fatalf("expected alice not %v (%v)", v, err)
+	}
+	if v, err := s.Get("Settings.smtp", account); err != nil || v != Redacted_mask {
+		t.Fatalf("expected masked map not %v (%v)", v, err)
+	}
+	if _, err := s.GetInt64("Credentials.Expiry", &account); !errors.Is(err, ErrRedacted) {
+		t.Fatalf("expected a redacted error not %v", err)
+	}
+	if _, err := s.GetInt64("Cards.0.Missing", account); errors.Is(err, ErrRedacted) {
+		t.Fatalf("expected a missing field not %v", err)
+	}
+	out, err := s.Render("{{User}}:{{Password | %q}}", account)
+	if err != nil || out != "alice:"+Redacted_mask {
+		t.Fatalf("expected masked render not %v (%v)", out, err)
+	}
+	tmpl := template.Must(template.New("t").Funcs(s.FuncMap(account)).Parse(`{{dq "Cards.0.Pin"}}`))
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, nil); err != nil || sb.String() != Redacted_mask {
+		t.Fatalf("expected masked pin not %v (%v)", sb.String(), err)
+	}
+	// expressions are evaluated on the real values
+	if ok, err := s.Eval("Password == 'hunter2'", account); err != nil || ok != true {
+		t.Fatalf("expected true not %v (%v)", ok, err)
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	s := NewSurfer(WithRedact("Password", "Settings.smtp.password"))
+	account := getAccount()
+	result, err := s.Query("*", account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]interface{}{}
+	for _, kv := range result {
+		values[kv.Key] = kv.Value
+	}
+	if values["User"] != "alice" || values["Password"] != Redacted_mask || values["Credentials"] != Redacted_mask {
+		t.Fatalf("expected masked fields not %v", values)
+	}
+	result, err = s.Query("Cards.*.Pin", account)
+	if err != nil || len(result) != 1 || result[0].Value != Redacted_mask {
+		t.Fatalf("expected masked pin not %v (%v)", result, err)
+	}
+	result, err = s.Query("Settings.smtp.*", account)
+	if err != nil || len(result) != 2 || result[0].Value != "localhost" || result[1].Value != Redacted_mask {
+		t.Fatalf("expected masked smtp password not %v (%v)", result, err)
+	}
+}
+
+func TestRedactSelect(t *testing.T) {
+	s := NewSurfer(WithRedact("Password"))
+	result, err := s.Select([]Account{getAccount()}, "User", "Password", "Cards.0.Pin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result[0]["User"] != "alice" || result[0]["Password"] != Redacted_mask || result[0]["Cards.0.Pin"] != Redacted_mask {
+		t.Fatalf("expected masked fields not %v", result)
+	}
+}
+
+func TestRedactEval(t *testing.T) {
+	s := NewSurfer(WithRedact("Password"))
+	account := getAccount()
+	for _, expr := range []string{"Password", "upper(Password)", "Cards.0.Pin + 1"} {
+		if v, err := s.Eval(expr, account); err != nil || v != Redacted_mask {
+			t.Fatalf("expression %v must be masked not %v (%v)", expr, v, err)
+		}
+	}
+	if v, err := s.Eval("len(Password) > 3", account); err != nil || v != true {
+		t.Fatalf("expected true not %v (%v)", v, err)
+	}
+	if v, err := s.Eval("upper(User)", account); err != nil || v != "ALICE" {
+		t.Fatalf("expected ALICE not %v (%v)", v, err)
+	}
+}
+
+func TestRedactParameters(t *testing.T) {
+	s := NewSurfer(WithRedact("Password"))
+	params := s.Parameters(getAccount())
+	for _, name := range []string{"Password", "Cards_0_Pin"} {
+		if v, err := params.Get(name); err != nil || v != Redacted_mask {
+			t.Fatalf("variable %v must be masked not %v (%v)", name, v, err)
+		}
+	}
+	if v, err := params.Get("User"); err != nil || v != "alice" {
+		t.Fatalf("expected alice not %v (%v)", v, err)
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	s := NewSurfer(WithRedact("**.password"), WithMasker(MaskKeepLast(2)))
+	type document struct {
+		data    []byte
+		flatten func([]byte) (map[string]interface{}, error)
+		get     func(string, []byte) (interface{}, error)
+	}
+	documents := map[string]document{
+		"json": {[]byte(`{"user": "alice", "smtp": {"password": "secret", "host": "localhost"}}`), s.FlattenJSON, s.GetFromJSON},
+		"yaml": {[]byte("user: alice\nsmtp:\n  password: secret\n  host: localhost\n"), s.FlattenYAML, s.GetFromYAML},
+		"toml": {[]byte("user = \"alice\"\n[smtp]\npassword = \"secret\"\nhost = \"localhost\"\n"), s.FlattenTOML, s.GetFromTOML},
+	}
+	for format, doc := range documents {
+		flat, err := doc.flatten(doc.data)
+		if err != nil {
+			t.Fatalf("%v: %v", format, err)
+		}
+		if flat["smtp.password"] != Redacted_mask+"et" || flat["smtp.host"] != "localhost" {
+			t.Fatalf("%v: expected masked password not %v", format, flat)
+		}
+		if v, err := doc.get("smtp.password", doc.data); err != nil || v != Redacted_mask+"et" {
+			t.Fatalf("%v: expected masked password not %v (%v)", format, v, err)
+		}
+		if v, err := doc.get("smtp.host", doc.data); err != nil || v != "localhost" {
+			t.Fatalf("%v: expected localhost not %v (%v)", format, v, err)
+		}
+	}
+}
+
+func TestRedactAggregate(t *testing.T) {
+	accounts := []Account{getAccount(), getAccount()}
+	accounts[1].Password = "letmein"
+	aggs := map[string]AggSpec{"n": {Func: AGG_COUNT}}
+	result, err := NewSurfer(WithRedact("Password")).Aggregate(accounts, []string{"Password"}, aggs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[Redacted_mask+".n"] != 2.0 {
+		t.Fatalf("expected the groups merged by the mask not %v", result)
+	}
+	result, err = NewSurfer(WithMasker(MaskHash())).Aggregate(accounts, []string{"Cards.0.Pin"}, aggs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key := range result {
+		if strings.Contains(key, "1234") {
+			t.Fatalf("group %v reveals the pin", key)
+		}
+	}
+}
+
+func TestRedactInvalidPattern(t *testing.T) {
+	s := NewSurfer(WithRedact("a..b"))
+	if !errors.Is(s.Err(), ErrInvalidPath) {
+		t.Fatalf("expected an invalid pattern not %v", s.Err())
+	}
+	if _, err := s.GetFlatData(getAccount()); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("expected an invalid pattern not %v", err)
+	}
+	if _, err := s.Get("Password", getAccount()); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("expected an invalid pattern not %v", err)
+	}
+	if _, err := s.Eval("Password", getAccount()); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("expected an invalid pattern not %v", err)
+	}
+	if _, err := s.Parameters(getAccount()).Get("Password"); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("expected an invalid pattern not %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+// render.go defines the rendering of strings with placeholders referencing the fields of the data
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// placeholder is a reference to a field within a template, with its optional format verb and default value
+type placeholder struct {
+	name       string
+	verb       string
+	def        string
+	hasDefault bool
+}
+
+// splitPlaceholder splits the content of a placeholder by the unescaped |, outside the quoted fields.
+// Outside the quoted fields a backslash escapes the following character.
+func splitPlaceholder(inner string) []string {
+	parts := []string{}
+	var sb strings.Builder
+	inQuote := false
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case inQuote:
+			sb.WriteByte(c)
+			if c == '\\' && i+1 < len(inner) {
+				i++
+				sb.WriteByte(inner[i])
+			} else if c == '"' {
+				inQuote = false
+			}
+		case c == '"':
+			inQuote = true
+			sb.WriteByte(c)
+		case c == '\\' && i+1 < len(inner):
+			i++
+			sb.WriteByte(inner[i])
+		case c == '|':
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return append(parts, sb.String())
+}
+
+// parsePlaceholder parses the content of a placeholder, e.g. "Alfa | %.2f | default: 0"
+func parsePlaceholder(inner string) (placeholder, error) {
+	parts := splitPlaceholder(inner)
+	p := placeholder{name: strings.TrimSpace(parts[0])}
+	if p.name == "" {
+		return p, fmt.Errorf("empty placeholder {{%v}}", inner)
+	}
+	for _, part := range parts[1:] {
+		filter := strings.TrimSpace(part)
+		switch {
+		case strings.HasPrefix(filter, "%"):
+			p.verb = filter
+		case strings.HasPrefix(filter, "default:"):
+			p.def = strings.TrimSpace(strings.TrimPrefix(filter, "default:"))
+			p.hasDefault = true
+		default:
+			return p, fmt.Errorf("unknown filter %v in {{%v}}", filter, inner)
+		}
+	}
+	return p, nil
+}
+
+// placeholderEnd returns the position of the }} closing the placeholder whose content starts at start,
+// skipping the quoted fields and the escaped characters
+func placeholderEnd(tmpl string, start int) (int, error) {
+	inQuote := false
+	for i := start; i < len(tmpl); i++ {
+		switch {
+		case tmpl[i] == '\\':
+			i++
+		case tmpl[i] == '"':
+			inQuote = !inQuote
+		case !inQuote && strings.HasPrefix(tmpl[i:], "}}"):
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated placeholder at position %v", start-2)
+}
+
+// formatVerb returns the string form of a value, following the format verb if any.
+// Numbers are converted to float64 for the verbs of floats and to int64 for the verbs of integers.
+func formatVerb(v interface{}, verb string) string {
+	if verb == "" {
+		return toString(v)
+	}
+	if f, ok := toFloat64(v); ok {
+		switch verb[len(verb)-1] {
+		case 'e', 'E', 'f', 'F', 'g', 'G':
+			return fmt.Sprintf(verb, f)
+		case 'd', 'x', 'X', 'o', 'b', 'c':
+			return fmt.Sprintf(verb, int64(f))
+		}
+	}
+	return fmt.Sprintf(verb, v)
+}
+
+// Render replaces the placeholders of a template with the values of the fields of the source, e.g.
+// "Order {{Gamma.Omega}} total {{Alfa | %.2f}}". Placeholders accept the filters:
+//
+//	| %verb          formats the value by means of fmt, e.g. %.2f or %05d
+//	| default: text  is used when the field is missing or a nil is on its path
+//
+// A backslash escapes the following character, so \{{ is a literal {{ and \| is a literal | within a default.
+// Fields are read by means of the getters, so they follow the path syntax of the Surfer and must be primitive.
+// Secret fields (see WithRedact) are masked, ignoring their format verb.
+func (s Surfer) Render(tmpl string, source interface{}) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		switch {
+		case tmpl[i] == '\\' && strings.HasPrefix(tmpl[i+1:], "{{"):
+			sb.WriteString("{{")
+			i += 2
+		case tmpl[i] == '\\' && strings.HasPrefix(tmpl[i+1:], "\\"):
+			sb.WriteByte('\\')
+			i++
+		case strings.HasPrefix(tmpl[i:], "{{"):
+			end, err := placeholderEnd(tmpl, i+2)
+			if err != nil {
+				return "", err
+			}
+			p, err := parsePlaceholder(tmpl[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			v, _, err := s.lookup(p.name, source)
+			switch {
+			case err == nil && s.redacted(p.name, source):
+				sb.WriteString(toString(s.mask(v)))
+			case err == nil:
+				sb.WriteString(formatVerb(v, p.verb))
+			case p.hasDefault && (errors.Is(err, ErrMissingField) || errors.Is(err, ErrNilOnPath)):
+				sb.WriteString(p.def)
+			default:
+				return "", fmt.Errorf("placeholder {{%v}}: %w", tmpl[i+2:end], err)
+			}
+			i = end + 1
+		default:
+			sb.WriteByte(tmpl[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+// FuncMap returns the functions for text/template reading the fields of the source:
+// {{dq "Gamma.Omega"}} returns the value of the field, {{dqf "Alfa" "%.2f"}} returns it formatted.
+// Functions must be defined before parsing a template, so they can be replaced for each source by means of Funcs.
+func (s Surfer) FuncMap(source interface{}) template.FuncMap {
+	return template.FuncMap{
+		"dq": func(name string) (interface{}, error) {
+			v, _, err := s.lookup(name, source)
+			if err == nil && s.redacted(name, source) {
+				return s.mask(v), nil
+			}
+			return v, err
+		},
+		"dqf": func(name string, verb string) (string, error) {
+			v, _, err := s.lookup(name, source)
+			if err != nil {
+				return "", err
+			}
+			if s.redacted(name, source) {
+				return toString(s.mask(v)), nil
+			}
+			return formatVerb(v, verb), nil
+		},
+	}
+}
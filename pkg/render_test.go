@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestRender(t *testing.T) {
+	s := NewSurfer()
+	data := getData()
+	data.Zeta["v1.2"] = 3.14159
+	tests := map[string]string{
+		"Order {{Gamma.Omega}} total {{Alfa}}":          "Order test2 total 1",
+		"total {{ Alfa | %.2f }}":                       "total 1.00",
+		"{{Gamma.Ypsilon|%05d}} {{Gamma.Ypsilon|%.1f}}": "00010 10.0",
+		`{{Zeta["v1.2"] | %.3f}}`:                       "3.142",
+		"{{Gamma.Epsilon.Delta | default: none}}":       "none",
+		"{{Gamma.Nothing|default:n\\|a}}":               "n|a",
+		"{{Gamma.Nothing | default:}}!":                 "!",
+		"literal \\{{Alfa}} and \\\\{{Alfa}}":           "literal {{Alfa}} and \\1",
+		"no placeholders }} here":                       "no placeholders }} here",
+		"{{Gamma.Omega | default: x | %q}}":             `"test2"`,
+	}
+	for tmpl, expected := range tests {
+		result, err := s.Render(tmpl, &data)
+		if err != nil {
+			t.Errorf("template %v: %v", tmpl, err)
+			continue
+		}
+		if result != expected {
+			t.Errorf("template %v must render %q not %q", tmpl, expected, result)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	s := NewSurfer()
+	tests := map[string]error{
+		"{{Gamma.Nothing}}":             ErrMissingField,
+		"{{Gamma.Epsilon.Delta}}":       ErrNilOnPath,
+		"{{Gamma | default: x}}":        ErrWrongType,
+		"{{Gamma..Omega | default: x}}": ErrInvalidPath,
+	}
+	for tmpl, kind := range tests {
+		if _, err := s.Render(tmpl, getData()); !errors.Is(err, kind) {
+			t.Errorf("template %v must fail with %v not %v", tmpl, kind, err)
+		}
+	}
+	for _, tmpl := range []string{"{{Alfa", "{{ }}", "{{Alfa | upper}}"} {
+		if _, err := s.Render(tmpl, getData()); err == nil {
+			t.Errorf("template %v must fail", tmpl)
+		}
+	}
+}
+
+func TestFuncMap(t *testing.T) {
+	s := NewSurfer()
+	tmpl, err := template.New("t").Funcs(s.FuncMap(nil)).Parse(`{{dq "Gamma.Omega"}} {{dqf "Alfa" "%.2f"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Funcs(s.FuncMap(getData())).Execute(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != "test2 1.00" {
+		t.Errorf("template must render \"test2 1.00\" not %q", sb.String())
+	}
+	if err := tmpl.Funcs(s.FuncMap(Level1{})).Execute(&sb, nil); err == nil {
+		t.Errorf("nil Gamma must fail")
+	}
+}
@@ -0,0 +1,95 @@
+// schema.go defines the introspection of the fields reachable from a Go type
+package pkg
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"reflect"
+)
+
+// FieldInfo describes a leaf field reachable from a Go type.
+// Keys of maps and indexes of lists are unknown before having data, so they appear as the wildcard * within the path.
+type FieldInfo struct {
+	// Path is the fully qualified name of the field, e.g. Gamma.Omega or Zeta.*
+	Path string
+	// Type is the custom standardization of the type (T_INT, T_STRING, ...), T_NOT_SUPPORTED for interfaces
+	Type int
+	// GoType is the type of the field
+	GoType reflect.Type
+	// Tag is the tag of the field, if it is the field of a struct
+	Tag reflect.StructTag
+	// Pointer is true if the field is reachable only through a pointer, which may be nil
+	Pointer bool
+	// Map is true if the field is within a map
+	Map bool
+	// List is true if the field is within a slice or an array
+	List bool
+}
+
+// joinWildcard returns the fully qualified name of the fields of a map or a list given the one of its parent
+func (s Surfer) joinWildcard(prefix string) string {
+	if prefix == "" {
+		return "*"
+	}
+	return prefix + s.sep + "*"
+}
+
+// schema appends to result the leaf fields reachable from the given type, whose fully qualified name is prefix.
+// The parent argument carries the pointer, map and list flags of the enclosing levels.
+func (s Surfer) schema(prefix string, t reflect.Type, tag reflect.StructTag, parent FieldInfo, stack map[reflect.Type]bool, result *[]FieldInfo) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		parent.Pointer = true
+		s.schema(prefix, t.Elem(), tag, parent, stack, result)
+	case reflect.Struct:
+		if stack[t] {
+			// recursive types are not expanded
+			log.Debugf("skipped field [%v] of recursive type %v", prefix, t)
+			return
+		}
+		stack[t] = true
+		defer delete(stack, t)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				// not exported
+				continue
+			}
+			s.schema(s.join(prefix, f.Name), f.Type, f.Tag, parent, stack, result)
+		}
+	case reflect.Map:
+		parent.Map = true
+		s.schema(s.joinWildcard(prefix), t.Elem(), tag, parent, stack, result)
+	case reflect.Slice, reflect.Array:
+		parent.List = true
+		s.schema(s.joinWildcard(prefix), t.Elem(), tag, parent, stack, result)
+	case reflect.Float64, reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float32, reflect.Interface:
+		parent.Path = prefix
+		parent.Type = kindtype(t.Kind())
+		parent.GoType = t
+		parent.Tag = tag
+		*result = append(*result, parent)
+	default:
+		log.Debugf("skipped field [%v] of not supported type %v", prefix, t)
+	}
+}
+
+// Schema returns all the leaf fields reachable from the given type, walking the type graph instead of the values,
+// so even the fields behind nil pointers are listed. Fields follow the same order of GetFlatDataOrdered.
+func (s Surfer) Schema(t reflect.Type) ([]FieldInfo, error) {
+	result := []FieldInfo{}
+	if t == nil {
+		return result, fmt.Errorf("type cannot be nil")
+	}
+	root := t
+	if root.Kind() == reflect.Ptr {
+		root = root.Elem()
+	}
+	switch root.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		s.schema("", root, "", FieldInfo{}, map[reflect.Type]bool{}, &result)
+		return result, nil
+	default:
+		return result, fmt.Errorf("unhandled type of data %v", root.Kind())
+	}
+}
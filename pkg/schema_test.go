@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+type Tagged struct {
+	Name   string `json:"name"`
+	Items  []Level3
+	Data   map[string]interface{}
+	Loop   *Tagged
+	hidden int
+}
+
+func TestSchema(t *testing.T) {
+	s := NewSurfer()
+	fields, err := s.Schema(reflect.TypeOf(getData()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []FieldInfo{
+		{Path: "Alfa", Type: T_FLOAT64, GoType: reflect.TypeOf(0.0)},
+		{Path: "Gamma.Ypsilon", Type: T_INT, GoType: reflect.TypeOf(0), Pointer: true},
+		{Path: "Gamma.Omega", Type: T_STRING, GoType: reflect.TypeOf(""), Pointer: true},
+		{Path: "Gamma.Epsilon.Delta", Type: T_INT, GoType: reflect.TypeOf(0), Pointer: true},
+		{Path: "Zeta.*", Type: T_FLOAT64, GoType: reflect.TypeOf(0.0), Map: true},
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("schema must be %v not %v", expected, fields)
+	}
+}
+
+func TestSchemaTagsAndLists(t *testing.T) {
+	s := NewSurfer(WithSep("_"))
+	fields, err := s.Schema(reflect.TypeOf(&Tagged{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{}
+	for _, f := range fields {
+		paths = append(paths, f.Path)
+	}
+	expected := []string{"Name", "Items_*_Delta", "Data_*"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("paths must be %v not %v", expected, paths)
+	}
+	if fields[0].Tag.Get("json") != "name" {
+		t.Errorf("tag of Name must be json:\"name\" not %v", fields[0].Tag)
+	}
+	if !fields[1].List || fields[1].Map {
+		t.Errorf("Items_*_Delta must be within a list %+v", fields[1])
+	}
+	if fields[2].Type != T_NOT_SUPPORTED || fields[2].GoType.Kind() != reflect.Interface {
+		t.Errorf("Data_* must be an interface %+v", fields[2])
+	}
+	if _, err := s.Schema(reflect.TypeOf(5)); err == nil {
+		t.Error("int has no fields")
+	}
+}
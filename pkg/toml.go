@@ -0,0 +1,57 @@
+// toml.go defines the Surfer's methods working on TOML documents
+package pkg
+
+import (
+	"github.com/BurntSushi/toml"
+	"time"
+)
+
+// normalizeTOML replaces the date-time values of a decoded TOML document with their RFC 3339 string form
+func normalizeTOML(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case time.Time:
+		return vv.Format(time.RFC3339Nano)
+	case map[string]interface{}:
+		for k := range vv {
+			vv[k] = normalizeTOML(vv[k])
+		}
+	case []map[string]interface{}:
+		for i := range vv {
+			normalizeTOML(vv[i])
+		}
+	case []interface{}:
+		for i := range vv {
+			vv[i] = normalizeTOML(vv[i])
+		}
+	}
+	return v
+}
+
+// decodeTOML unmarshals a TOML document
+func decodeTOML(data []byte) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return nil, err
+	}
+	normalizeTOML(doc)
+	return doc, nil
+}
+
+// GetFromTOML returns the value of the given field from a TOML document, date-times are returned as RFC 3339 strings.
+// Secret fields are masked (see WithRedact).
+func (s Surfer) GetFromTOML(name string, data []byte) (interface{}, error) {
+	doc, err := decodeTOML(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(name, doc)
+}
+
+// FlattenTOML returns a map of interface{} including all primitive values of a TOML document
+func (s Surfer) FlattenTOML(data []byte) (map[string]interface{}, error) {
+	doc, err := decodeTOML(data)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	return s.GetFlatData(doc)
+}
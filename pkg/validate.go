@@ -0,0 +1,184 @@
+// validate.go defines the validation of Govaluate expressions against the schema of a Go type
+package pkg
+
+import (
+	"fmt"
+	"github.com/Knetic/govaluate"
+	"reflect"
+	"strings"
+)
+
+// categories of operands used to detect type mismatches
+const (
+	c_UNKNOWN = iota
+	c_NUMBER
+	c_STRING
+	c_BOOL
+)
+
+// ExpressionError reports the unknown variables and the type mismatches found within an expression
+type ExpressionError struct {
+	Unknown    []string
+	Mismatches []string
+}
+
+func (e *ExpressionError) Error() string {
+	problems := []string{}
+	if len(e.Unknown) > 0 {
+		problems = append(problems, "unknown variables: "+strings.Join(e.Unknown, ", "))
+	}
+	if len(e.Mismatches) > 0 {
+		problems = append(problems, "type mismatches: "+strings.Join(e.Mismatches, ", "))
+	}
+	return strings.Join(problems, "; ")
+}
+
+// matchField checks if the fields of a variable match the fields of a schema's path, where wildcards match any key.
+// Below an interface any field may exist.
+func matchField(fields []string, info FieldInfo, pattern []string) bool {
+	if len(fields) < len(pattern) {
+		return false
+	}
+	if len(fields) > len(pattern) && info.GoType.Kind() != reflect.Interface {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != wildcard && pattern[i] != fields[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// category returns the category of a field of the schema
+func category(info FieldInfo) int {
+	switch info.Type {
+	case T_INT, T_INT64, T_FLOAT32, T_FLOAT64:
+		return c_NUMBER
+	case T_STRING:
+		return c_STRING
+	case T_BOOL:
+		return c_BOOL
+	default:
+		return c_UNKNOWN
+	}
+}
+
+// categoryNames are used to report the mismatches
+var categoryNames = map[int]string{
+	c_NUMBER: "number",
+	c_STRING: "string",
+	c_BOOL:   "bool",
+}
+
+// ValidateExpression checks a Govaluate expression against the schema of the given type before having any data.
+// Each variable must match a reachable field, map's keys and list's indexes matching any value; variables may use "_"
+// in place of the separator as accepted by Parameters. Operands next to comparators and arithmetic operators
+// must have compatible types, e.g. a string field cannot be compared with a number.
+// The returned error is an *ExpressionError, unless the expression cannot be parsed.
+func (s Surfer) ValidateExpression(expr string, typ reflect.Type) error {
+	e, err := govaluate.NewEvaluableExpression(expr)
+	if err != nil {
+		return err
+	}
+	schema, err := s.Schema(typ)
+	if err != nil {
+		return err
+	}
+	patterns := make([][]string, len(schema))
+	for i, info := range schema {
+		if patterns[i], err = parsePath(info.Path, s.sep); err != nil {
+			return err
+		}
+	}
+	// resolve returns the category of the field matching a variable
+	resolve := func(name string) (int, bool) {
+		candidates := []string{name}
+		if s.sep != Govaluate_sep && strings.Contains(name, Govaluate_sep) {
+			candidates = append(candidates, strings.ReplaceAll(name, Govaluate_sep, s.sep))
+		}
+		for _, candidate := range candidates {
+			fields, err := parsePath(candidate, s.sep)
+			if err != nil {
+				continue
+			}
+			for i, info := range schema {
+				if matchField(fields, info, patterns[i]) {
+					return category(info), true
+				}
+			}
+		}
+		return c_UNKNOWN, false
+	}
+	result := &ExpressionError{}
+	seen := map[string]bool{}
+	for _, v := range e.Vars() {
+		if _, ok := resolve(v); !ok && !seen[v] {
+			result.Unknown = append(result.Unknown, v)
+		}
+		seen[v] = true
+	}
+	tokens := e.Tokens()
+	// operand returns the category of the token at index i, if it is a simple operand
+	operand := func(i int) (int, bool) {
+		if i < 0 || i >= len(tokens) {
+			return c_UNKNOWN, false
+		}
+		switch tokens[i].Kind {
+		case govaluate.NUMERIC:
+			return c_NUMBER, true
+		case govaluate.STRING, govaluate.PATTERN:
+			return c_STRING, true
+		case govaluate.BOOLEAN:
+			return c_BOOL, true
+		case govaluate.VARIABLE:
+			c, _ := resolve(tokens[i].Value.(string))
+			return c, true
+		default:
+			return c_UNKNOWN, false
+		}
+	}
+	// bound checks if the token at index i is bound to a stronger operator than a comparator
+	bound := func(i int) bool {
+		return i >= 0 && i < len(tokens) && (tokens[i].Kind == govaluate.MODIFIER || tokens[i].Kind == govaluate.PREFIX)
+	}
+	for i, t := range tokens {
+		if i == 0 || (t.Kind != govaluate.COMPARATOR && t.Kind != govaluate.MODIFIER) {
+			continue
+		}
+		if tokens[i-1].Kind != govaluate.VARIABLE && (i+1 >= len(tokens) || tokens[i+1].Kind != govaluate.VARIABLE) {
+			continue
+		}
+		left, lok := operand(i - 1)
+		right, rok := operand(i + 1)
+		if !lok || !rok || left == c_UNKNOWN || right == c_UNKNOWN {
+			continue
+		}
+		if t.Kind == govaluate.COMPARATOR && (bound(i-2) || bound(i+2)) {
+			continue
+		}
+		op := fmt.Sprint(t.Value)
+		mismatch := false
+		switch op {
+		case "+":
+			// strings are concatenated with anything
+			mismatch = left != right && left != c_STRING && right != c_STRING
+		case "=~", "!~":
+			mismatch = left != c_STRING || right != c_STRING
+		case "in":
+			continue
+		case "==", "!=":
+			mismatch = left != right
+		default:
+			mismatch = left != right || (t.Kind == govaluate.MODIFIER && left != c_NUMBER)
+		}
+		if mismatch {
+			result.Mismatches = append(result.Mismatches, fmt.Sprintf("%v %v %v (%v %v %v)",
+				tokens[i-1].Value, op, tokens[i+1].Value, categoryNames[left], op, categoryNames[right]))
+		}
+	}
+	if len(result.Unknown) > 0 || len(result.Mismatches) > 0 {
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateExpression(t *testing.T) {
+	s := NewSurfer()
+	typ := reflect.TypeOf(getData())
+	valid := []string{
+		Expression,
+		"Gamma_Omega == 'test2' && Alfa > 0",
+		"Zeta_zeta1 * 2 > Alfa",
+		"[Gamma.Epsilon.Delta] >= 1",
+		"Gamma_Omega + Alfa",
+	}
+	for _, expr := range valid {
+		if err := s.ValidateExpression(expr, typ); err != nil {
+			t.Errorf("expression %v must be valid: %v", expr, err)
+		}
+	}
+}
+
+func TestValidateExpressionErrors(t *testing.T) {
+	s := NewSurfer()
+	typ := reflect.TypeOf(&Level1{})
+	tests := map[string]ExpressionError{
+		"Gamma_Ypslon > 5":               {Unknown: []string{"Gamma_Ypslon"}},
+		"beta == 'test1'":                {Unknown: []string{"beta"}},
+		"Gamma_Omega > 5":                {Mismatches: []string{"Gamma_Omega > 5 (string > number)"}},
+		"Alfa == 'one' || Gamma_Ypsilon": {Mismatches: []string{"Alfa == one (number == string)"}},
+		"Gamma_Omega * 2 > Alfa":         {Mismatches: []string{"Gamma_Omega * 2 (string * number)"}},
+		"Alfa =~ 'a.*'":                  {Mismatches: []string{"Alfa =~ a.* (number =~ string)"}},
+	}
+	for expr, expected := range tests {
+		err := s.ValidateExpression(expr, typ)
+		var e *ExpressionError
+		if !errors.As(err, &e) {
+			t.Errorf("expression %v must return an ExpressionError not %v", expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(e.Unknown, expected.Unknown) || !reflect.DeepEqual(e.Mismatches, expected.Mismatches) {
+			t.Errorf("expression %v must return %v not %v", expr, expected.Error(), e.Error())
+		}
+	}
+	if err := s.ValidateExpression("Alfa >", typ); err == nil {
+		t.Errorf("malformed expression must fail")
+	}
+}
+
+func TestValidateExpressionMaps(t *testing.T) {
+	s := NewSurfer(WithSep("/"))
+	typ := reflect.TypeOf(Tagged{})
+	valid := []string{
+		"Data_anything_below > 1",
+		"[Items/0/Delta] > 1",
+		"Name == 'x'",
+	}
+	for _, expr := range valid {
+		if err := s.ValidateExpression(expr, typ); err != nil {
+			t.Errorf("expression %v must be valid: %v", expr, err)
+		}
+	}
+	if err := s.ValidateExpression("Items_0_Delta == 'x'", typ); err == nil {
+		t.Errorf("comparing an int with a string must fail")
+	}
+}
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	l1 := getData()
+	s := NewSurfer()
+	kvs, err := s.GetFlatDataOrdered(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := 0
+	err = s.Walk(&l1, func(path string, value interface{}) error {
+		if path != kvs[i].Key {
+			t.Errorf("field %v must be %v not %v", i, kvs[i].Key, path)
+		}
+		i++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != len(kvs) {
+		t.Errorf("expected %v fields not %v", len(kvs), i)
+	}
+}
+
+func TestWalkStop(t *testing.T) {
+	l1 := getData()
+	s := NewSurfer()
+	visited := []string{}
+	err := s.Walk(l1, func(path string, value interface{}) error {
+		visited = append(visited, path)
+		if path == "Gamma.Ypsilon" {
+			return ErrStopWalk
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(visited) != 2 {
+		t.Errorf("walk must stop after 2 fields not %v", visited)
+	}
+	failure := errors.New("failure")
+	err = s.Walk(l1, func(path string, value interface{}) error {
+		return failure
+	})
+	if err != failure {
+		t.Errorf("walk must return the error of the function not %v", err)
+	}
+	if err := s.Walk(5, func(path string, value interface{}) error { return nil }); err == nil {
+		t.Error("an int cannot be walked")
+	}
+}
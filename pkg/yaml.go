@@ -0,0 +1,53 @@
+// yaml.go defines the Surfer's methods working on YAML documents
+package pkg
+
+import (
+	"gopkg.in/yaml.v2"
+	"reflect"
+)
+
+// normalizeKeys replaces the maps of a decoded YAML document, whose keys may be of any type,
+// with maps having the string form of the keys (see keyString)
+func normalizeKeys(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, value := range vv {
+			m[keyString(reflect.ValueOf(k))] = normalizeKeys(value)
+		}
+		return m
+	case []interface{}:
+		for i := range vv {
+			vv[i] = normalizeKeys(vv[i])
+		}
+	}
+	return v
+}
+
+// decodeYAML unmarshals a YAML document, whose keys are normalised to their string form
+func decodeYAML(data []byte) (interface{}, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return normalizeKeys(doc), nil
+}
+
+// GetFromYAML returns the value of the given field from a YAML document, masked if it is secret (see WithRedact)
+func (s Surfer) GetFromYAML(name string, data []byte) (interface{}, error) {
+	doc, err := decodeYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(name, doc)
+}
+
+// FlattenYAML returns a map of interface{} including all primitive values of a YAML document.
+// Keys of the document which are not strings are normalised to their string form.
+func (s Surfer) FlattenYAML(data []byte) (map[string]interface{}, error) {
+	doc, err := decodeYAML(data)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	return s.GetFlatData(doc)
+}
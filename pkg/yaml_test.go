@@ -0,0 +1,25 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenYAMLNullKey(t *testing.T) {
+	s := NewSurfer()
+	flat, err := s.FlattenYAML([]byte("null: 1\na: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"null": 1, "a": 2}
+	if !reflect.DeepEqual(flat, expected) {
+		t.Fatalf("expected %v not %v", expected, flat)
+	}
+	v, err := s.GetFromYAML("m.null", []byte("m:\n  ~: x\n"))
+	if err != nil || v != "x" {
+		t.Fatalf("expected x not %v (%v)", v, err)
+	}
+	if _, err := DecodeDocument([]byte("~: x\n")); err != nil {
+		t.Fatal(err)
+	}
+}
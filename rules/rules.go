@@ -0,0 +1,239 @@
+// Package rules evaluates named Govaluate rules against any data structure by means of the Surfer of DataQ
+//
+// Rules are loaded from YAML or JSON documents:
+//
+//	rules:
+//	  - id: low-total
+//	    expression: orders_0_total < 100
+//	    severity: warning
+//	    message: "first order total is {{.orders_0_total}}"
+//
+// Each evaluation reports which rules fired and the values of the variables read by each rule.
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/Knetic/govaluate"
+	dataq "github.com/LosAngeles971/DataQ/pkg"
+	"gopkg.in/yaml.v2"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Rule is a named boolean expression, which fires when it evaluates to true
+type Rule struct {
+	// ID identifies the rule, it must be unique within an engine
+	ID string `json:"id" yaml:"id"`
+	// Expression is a Govaluate expression, whose variables are fully qualified names (see Surfer.Parameters).
+	// Secret fields (see WithRedact) are read masked.
+	Expression string `json:"expression" yaml:"expression"`
+	// Severity is free text classifying the rule, e.g. info, warning or error
+	Severity string `json:"severity" yaml:"severity"`
+	// Message is a text/template rendered when the rule fires, e.g. "total is {{.Orders_0_Total}}",
+	// whose fields are resolved like the variables of the expression
+	Message string `json:"message" yaml:"message"`
+}
+
+// Result is the outcome of a rule against a source
+type Result struct {
+	Rule Rule
+	// Fired is true if the expression evaluated to true
+	Fired bool
+	// Message is the rendered message of the rule, only if it fired
+	Message string
+	// Vars are the variables read by the expression with their values, the secret ones masked, for explainability
+	Vars map[string]interface{}
+	// Err is the failure of the evaluation, if any
+	Err error
+}
+
+// compiled is a rule ready to be evaluated
+type compiled struct {
+	rule       Rule
+	expression *govaluate.EvaluableExpression
+	message    *template.Template
+	// fields are the names of the variables used by the message
+	fields []string
+}
+
+// Engine evaluates a list of rules in order
+type Engine struct {
+	s     *dataq.Surfer
+	rules []compiled
+}
+
+// recorder is a govaluate.Parameters recording the variables read by an expression,
+// as returned by Surfer.Parameters so the secret ones are masked
+type recorder struct {
+	params govaluate.Parameters
+	vars   map[string]interface{}
+}
+
+// Get returns the value of a variable and records it
+func (r *recorder) Get(name string) (interface{}, error) {
+	v, err := r.params.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	r.vars[name] = v
+	return v, nil
+}
+
+// templateFields appends to fields the names of the fields used by a node of a template, e.g. Name for {{.Name}}
+func templateFields(node parse.Node, fields *[]string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n != nil {
+			for _, child := range n.Nodes {
+				templateFields(child, fields)
+			}
+		}
+	case *parse.ActionNode:
+		templateFields(n.Pipe, fields)
+	case *parse.PipeNode:
+		if n != nil {
+			for _, cmd := range n.Cmds {
+				templateFields(cmd, fields)
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			templateFields(arg, fields)
+		}
+	case *parse.FieldNode:
+		*fields = append(*fields, n.Ident[0])
+	case *parse.IfNode:
+		templateFields(n.Pipe, fields)
+		templateFields(n.List, fields)
+		templateFields(n.ElseList, fields)
+	case *parse.RangeNode:
+		templateFields(n.Pipe, fields)
+		templateFields(n.List, fields)
+		templateFields(n.ElseList, fields)
+	case *parse.WithNode:
+		templateFields(n.Pipe, fields)
+		templateFields(n.List, fields)
+		templateFields(n.ElseList, fields)
+	}
+}
+
+// New returns an engine evaluating the given rules by means of the given Surfer.
+// Rules must have a unique id and a valid expression and message.
+func New(s *dataq.Surfer, rules []Rule) (*Engine, error) {
+	e := &Engine{s: s}
+	ids := map[string]bool{}
+	for i, r := range rules {
+		if r.ID == "" {
+			return nil, fmt.Errorf("rule %v has no id", i)
+		}
+		if ids[r.ID] {
+			return nil, fmt.Errorf("rule %v is defined twice", r.ID)
+		}
+		ids[r.ID] = true
+		if strings.TrimSpace(r.Expression) == "" {
+			return nil, fmt.Errorf("rule %v has no expression", r.ID)
+		}
+		expression, err := govaluate.NewEvaluableExpression(r.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("rule %v: %v", r.ID, err)
+		}
+		message, err := template.New(r.ID).Parse(r.Message)
+		if err != nil {
+			return nil, fmt.Errorf("rule %v: %v", r.ID, err)
+		}
+		c := compiled{rule: r, expression: expression, message: message}
+		templateFields(message.Tree.Root, &c.fields)
+		e.rules = append(e.rules, c)
+	}
+	return e, nil
+}
+
+// Load returns an engine evaluating the rules of a YAML or JSON document, which lists them under the key "rules".
+// Documents starting with { are decoded as JSON, the other ones as YAML.
+func Load(s *dataq.Surfer, data []byte) (*Engine, error) {
+	doc := struct {
+		Rules []Rule `json:"rules" yaml:"rules"`
+	}{}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, err
+		}
+	} else if err := yaml.UnmarshalStrict(data, &doc); err != nil {
+		return nil, err
+	}
+	return New(s, doc.Rules)
+}
+
+// Rules returns the rules of the engine, in order
+func (e *Engine) Rules() []Rule {
+	result := make([]Rule, len(e.rules))
+	for i, c := range e.rules {
+		result[i] = c.rule
+	}
+	return result
+}
+
+// evaluate returns the result of a rule against the source
+func (e *Engine) evaluate(c compiled, source interface{}) Result {
+	r := &recorder{params: e.s.Parameters(source), vars: map[string]interface{}{}}
+	result := Result{Rule: c.rule, Vars: r.vars}
+	value, err := c.expression.Eval(r)
+	if err != nil {
+		result.Err = fmt.Errorf("rule %v: %w", c.rule.ID, err)
+		return result
+	}
+	fired, ok := value.(bool)
+	if !ok {
+		result.Err = fmt.Errorf("rule %v must evaluate to a bool not %v", c.rule.ID, value)
+		return result
+	}
+	result.Fired = fired
+	if fired {
+		// the fields of the message which are not variables of the expression are resolved too
+		data := map[string]interface{}{}
+		for k, v := range r.vars {
+			data[k] = v
+		}
+		for _, f := range c.fields {
+			if _, ok := data[f]; !ok {
+				if v, err := r.params.Get(f); err == nil {
+					data[f] = v
+				}
+			}
+		}
+		var sb strings.Builder
+		if err := c.message.Execute(&sb, data); err != nil {
+			result.Err = fmt.Errorf("rule %v: %w", c.rule.ID, err)
+			return result
+		}
+		result.Message = sb.String()
+	}
+	return result
+}
+
+// Evaluate returns the results of all the rules against the source, in order.
+// A rule failing, e.g. because of a missing field, does not stop the others and it reports the failure within its result.
+func (e *Engine) Evaluate(source interface{}) []Result {
+	results := make([]Result, 0, len(e.rules))
+	for _, c := range e.rules {
+		results = append(results, e.evaluate(c, source))
+	}
+	return results
+}
+
+// Fired returns only the results of the rules which fired
+func Fired(results []Result) []Result {
+	fired := []Result{}
+	for _, r := range results {
+		if r.Fired {
+			fired = append(fired, r)
+		}
+	}
+	return fired
+}
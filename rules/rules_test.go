@@ -0,0 +1,157 @@
+package rules
+
+import (
+	"errors"
+	dataq "github.com/LosAngeles971/DataQ/pkg"
+	"reflect"
+	"testing"
+)
+
+type Order struct {
+	Total float64
+	Paid  bool
+}
+
+type Customer struct {
+	Name   string
+	Age    int
+	Orders []Order
+	Notes  *string
+}
+
+const (
+	RR = `
+rules:
+  - id: adult
+    expression: Age >= 18
+    severity: info
+    message: "{{.Name}} is {{.Age}}"
+  - id: unpaid
+    expression: Orders_0_Paid == false && Orders_0_Total > 100
+    severity: error
+    message: "first order of {{.Orders_0_Total}} is not paid"
+  - id: notes
+    expression: Notes == 'vip'
+    severity: warning
+`
+	JJ = `{"rules": [{"id": "young", "expression": "Age < 18 || Name == 'Bob'", "severity": "info"}]}`
+)
+
+func getCustomer() Customer {
+	return Customer{
+		Name:   "Alice",
+		Age:    30,
+		Orders: []Order{{Total: 150, Paid: false}, {Total: 50, Paid: true}},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	e, err := Load(dataq.NewSurfer(), []byte(RR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := e.Evaluate(getCustomer())
+	if len(results) != 3 {
+		t.Fatalf("results must be 3 not %v", len(results))
+	}
+	adult := results[0]
+	if !adult.Fired || adult.Err != nil || adult.Message != "Alice is 30" {
+		t.Errorf("rule adult must fire with message \"Alice is 30\" not %+v", adult)
+	}
+	if !reflect.DeepEqual(adult.Vars, map[string]interface{}{"Age": 30}) {
+		t.Errorf("rule adult must read only Age not %v", adult.Vars)
+	}
+	unpaid := results[1]
+	expected := map[string]interface{}{"Orders_0_Paid": false, "Orders_0_Total": 150.0}
+	if !unpaid.Fired || !reflect.DeepEqual(unpaid.Vars, expected) {
+		t.Errorf("rule unpaid must fire reading %v not %+v", expected, unpaid)
+	}
+	if unpaid.Message != "first order of 150 is not paid" || unpaid.Rule.Severity != "error" {
+		t.Errorf("rule unpaid has the wrong message or severity: %+v", unpaid)
+	}
+	notes := results[2]
+	if notes.Fired || !errors.Is(notes.Err, dataq.ErrNilOnPath) && !errors.Is(notes.Err, dataq.ErrWrongType) {
+		t.Errorf("rule notes must fail on the nil pointer not %+v", notes)
+	}
+	fired := Fired(results)
+	if len(fired) != 2 || fired[0].Rule.ID != "adult" || fired[1].Rule.ID != "unpaid" {
+		t.Errorf("fired rules must be adult and unpaid not %+v", fired)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	e, err := Load(dataq.NewSurfer(), []byte(JJ))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules := e.Rules(); len(rules) != 1 || rules[0].ID != "young" {
+		t.Fatalf("rules must be [young] not %v", rules)
+	}
+	result := e.Evaluate(getCustomer())[0]
+	if result.Fired || result.Err != nil {
+		t.Errorf("rule young must not fire: %+v", result)
+	}
+	// the right operand of || is read only if the left one is false
+	if !reflect.DeepEqual(result.Vars, map[string]interface{}{"Age": 30, "Name": "Alice"}) {
+		t.Errorf("rule young must read Age and Name not %v", result.Vars)
+	}
+}
+
+type User struct {
+	Name     string
+	Password string `dataq:"secret"`
+	Token    string
+}
+
+func TestEvaluateRedacted(t *testing.T) {
+	e, err := New(dataq.NewSurfer(dataq.WithRedact("Token")), []Rule{
+		{ID: "weak", Expression: "Name == 'bob' || Password != '' || Token != ''", Message: "{{.Password}} {{.Token}}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := e.Evaluate(User{Name: "alice", Password: "hunter2", Token: "abc"})[0]
+	if !result.Fired || result.Err != nil {
+		t.Fatalf("rule weak must fire: %+v", result)
+	}
+	expected := map[string]interface{}{"Name": "alice", "Password": dataq.Redacted_mask}
+	if !reflect.DeepEqual(result.Vars, expected) {
+		t.Errorf("variables must be %v not %v", expected, result.Vars)
+	}
+	if result.Message != dataq.Redacted_mask+" "+dataq.Redacted_mask {
+		t.Errorf("message must be masked not %v", result.Message)
+	}
+}
+
+func TestRuleErrors(t *testing.T) {
+	customer := getCustomer()
+	e, err := New(dataq.NewSurfer(), []Rule{
+		{ID: "missing", Expression: "Nothing > 1"},
+		{ID: "number", Expression: "Age + 1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := e.Evaluate(&customer)
+	if !errors.Is(results[0].Err, dataq.ErrMissingField) {
+		t.Errorf("rule missing must fail with %v not %v", dataq.ErrMissingField, results[0].Err)
+	}
+	if results[1].Err == nil {
+		t.Errorf("rule number must fail because it is not a bool")
+	}
+	invalid := map[string][]Rule{
+		"no id":         {{Expression: "Age > 1"}},
+		"duplicated id": {{ID: "a", Expression: "Age > 1"}, {ID: "a", Expression: "Age > 2"}},
+		"no expression": {{ID: "a"}},
+		"bad expr":      {{ID: "a", Expression: "Age >"}},
+		"bad message":   {{ID: "a", Expression: "Age > 1", Message: "{{.Age"}},
+	}
+	for name, rules := range invalid {
+		if _, err := New(dataq.NewSurfer(), rules); err == nil {
+			t.Errorf("rules with %v must fail", name)
+		}
+	}
+	if _, err := Load(dataq.NewSurfer(), []byte("rules:\n  - id: a\n    expr: Age > 1\n")); err == nil {
+		t.Errorf("unknown keys must fail")
+	}
+}
@@ -0,0 +1,234 @@
+// Package server exposes the Surfer of DataQ by means of an HTTP handler accepting JSON documents
+//
+// Endpoints, all accepting POST requests with a JSON body:
+//
+//	/flatten  {"document": {...}, "sep": "."}                          -> {"data": {"a.b": 1}}
+//	/get      {"document": {...}, "paths": ["a.b"], "sep": "."}        -> {"values": {"a.b": 1}}
+//	/eval     {"document": {...}, "expression": "a.b > 0", "sep": "."} -> {"result": true}
+//
+// Failures are returned as {"error": {"code": "missing_field", "message": "...", "path": "a.c"}},
+// where the code classifies the failure.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	dataq "github.com/LosAngeles971/DataQ/pkg"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+const (
+	// Default_max_body_size is the maximum size in bytes of the body of a request, by default
+	Default_max_body_size = 1 << 20
+)
+
+// codes classifying the failures within the error bodies
+const (
+	CODE_BAD_REQUEST        = "bad_request"
+	CODE_TOO_LARGE          = "too_large"
+	CODE_NOT_FOUND          = "not_found"
+	CODE_METHOD_NOT_ALLOWED = "method_not_allowed"
+	CODE_INVALID_PATH       = "invalid_path"
+	CODE_MISSING_FIELD      = "missing_field"
+	CODE_NIL_ON_PATH        = "nil_on_path"
+	CODE_WRONG_TYPE         = "wrong_type"
+	CODE_INVALID_EXPRESSION = "invalid_expression"
+	CODE_EVAL_FAILED        = "eval_failed"
+	CODE_INTERNAL           = "internal"
+)
+
+type handler struct {
+	maxBodySize int64
+	mux         *http.ServeMux
+}
+
+type HandlerOption func(*handler)
+
+// WithMaxBodySize sets the maximum size in bytes of the body of a request, larger requests fail with 413
+func WithMaxBodySize(size int64) HandlerOption {
+	return func(h *handler) {
+		h.maxBodySize = size
+	}
+}
+
+// request is the body of all the requests, each endpoint uses only some fields
+type request struct {
+	Document   interface{} `json:"document"`
+	Paths      []string    `json:"paths"`
+	Expression string      `json:"expression"`
+	Sep        string      `json:"sep"`
+}
+
+// apiError is the body of a failed request
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+}
+
+// failure is an error with its HTTP status and its classification
+type failure struct {
+	status int
+	body   apiError
+}
+
+func (f *failure) Error() string {
+	return f.body.Message
+}
+
+// fail returns a failure with the given status and code
+func fail(status int, code string, format string, a ...interface{}) *failure {
+	return &failure{status: status, body: apiError{Code: code, Message: fmt.Sprintf(format, a...)}}
+}
+
+// classify returns the failure of reading a field, given the error of the Surfer
+func classify(path string, err error) *failure {
+	var f *failure
+	switch {
+	case errors.Is(err, dataq.ErrInvalidPath):
+		f = fail(http.StatusBadRequest, CODE_INVALID_PATH, "%v", err)
+	case errors.Is(err, dataq.ErrInvalidExpression):
+		f = fail(http.StatusBadRequest, CODE_INVALID_EXPRESSION, "%v", err)
+	case errors.Is(err, dataq.ErrMissingField):
+		f = fail(http.StatusUnprocessableEntity, CODE_MISSING_FIELD, "%v", err)
+	case errors.Is(err, dataq.ErrNilOnPath):
+		f = fail(http.StatusUnprocessableEntity, CODE_NIL_ON_PATH, "%v", err)
+	case errors.Is(err, dataq.ErrWrongType):
+		f = fail(http.StatusUnprocessableEntity, CODE_WRONG_TYPE, "%v", err)
+	default:
+		f = fail(http.StatusUnprocessableEntity, CODE_EVAL_FAILED, "%v", err)
+	}
+	f.body.Path = path
+	return f
+}
+
+// NewHandler returns the handler of the endpoints /flatten, /get and /eval
+func NewHandler(opts ...HandlerOption) http.Handler {
+	h := &handler{
+		maxBodySize: Default_max_body_size,
+		mux:         http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.mux.HandleFunc("/flatten", h.endpoint(flatten))
+	h.mux.HandleFunc("/get", h.endpoint(get))
+	h.mux.HandleFunc("/eval", h.endpoint(eval))
+	h.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeFailure(w, fail(http.StatusNotFound, CODE_NOT_FOUND, "unknown endpoint %v", r.URL.Path))
+	})
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// writeJSON writes a JSON body with the given status
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(map[string]apiError{"error": {Code: CODE_INTERNAL, Message: err.Error()}})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+// writeFailure writes the error body of a failure
+func writeFailure(w http.ResponseWriter, f *failure) {
+	writeJSON(w, f.status, map[string]apiError{"error": f.body})
+}
+
+// readRequest decodes the body of a request, rejecting the ones larger than the limit
+func (h *handler) readRequest(r *http.Request) (*request, *failure) {
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, h.maxBodySize+1))
+	if err != nil {
+		return nil, fail(http.StatusBadRequest, CODE_BAD_REQUEST, "cannot read the request: %v", err)
+	}
+	if int64(len(data)) > h.maxBodySize {
+		return nil, fail(http.StatusRequestEntityTooLarge, CODE_TOO_LARGE, "request larger than %v bytes", h.maxBodySize)
+	}
+	req := &request{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(req); err != nil {
+		return nil, fail(http.StatusBadRequest, CODE_BAD_REQUEST, "invalid request: %v", err)
+	}
+	if dec.More() {
+		return nil, fail(http.StatusBadRequest, CODE_BAD_REQUEST, "invalid request: data after the JSON object")
+	}
+	switch req.Document.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return nil, fail(http.StatusBadRequest, CODE_BAD_REQUEST, "document must be a JSON object or array")
+	}
+	if req.Sep == "" {
+		req.Sep = dataq.Default_sep
+	}
+	return req, nil
+}
+
+// endpoint returns the handler of an endpoint, which receives a Surfer using the separator of the request
+func (h *handler) endpoint(fn func(s *dataq.Surfer, req *request) (interface{}, *failure)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeFailure(w, fail(http.StatusMethodNotAllowed, CODE_METHOD_NOT_ALLOWED, "method %v not allowed", r.Method))
+			return
+		}
+		req, f := h.readRequest(r)
+		if f != nil {
+			writeFailure(w, f)
+			return
+		}
+		body, f := fn(dataq.NewSurfer(dataq.WithSep(req.Sep)), req)
+		if f != nil {
+			writeFailure(w, f)
+			return
+		}
+		writeJSON(w, http.StatusOK, body)
+	}
+}
+
+// flatten returns the flat data of the document
+func flatten(s *dataq.Surfer, req *request) (interface{}, *failure) {
+	data, err := s.GetFlatData(req.Document)
+	if err != nil {
+		return nil, classify("", err)
+	}
+	return map[string]interface{}{"data": data}, nil
+}
+
+// get returns the values of the requested paths, failing on the first one which cannot be read
+func get(s *dataq.Surfer, req *request) (interface{}, *failure) {
+	if len(req.Paths) == 0 {
+		return nil, fail(http.StatusBadRequest, CODE_BAD_REQUEST, "paths cannot be empty")
+	}
+	values := map[string]interface{}{}
+	for _, path := range req.Paths {
+		value, err := s.Get(path, req.Document)
+		if err != nil {
+			return nil, classify(path, err)
+		}
+		values[path] = value
+	}
+	return map[string]interface{}{"values": values}, nil
+}
+
+// eval returns the result of the expression against the document
+func eval(s *dataq.Surfer, req *request) (interface{}, *failure) {
+	if req.Expression == "" {
+		return nil, fail(http.StatusBadRequest, CODE_BAD_REQUEST, "expression cannot be empty")
+	}
+	result, err := s.Eval(req.Expression, req.Document)
+	if err != nil {
+		return nil, classify("", err)
+	}
+	return map[string]interface{}{"result": result}, nil
+}
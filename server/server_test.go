@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const doc = `{"gamma": {"omega": "test2", "ypsilon": 10, "epsilon": null}, "orders": [{"total": 150}, {"total": 50}]}`
+
+// post sends a request to the handler and decodes the JSON response
+func post(t *testing.T, h http.Handler, path string, body string) (int, map[string]interface{}) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("content type must be application/json not %v", ct)
+	}
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("response %q is not JSON: %v", rec.Body.String(), err)
+	}
+	return rec.Code, result
+}
+
+func TestEndpoints(t *testing.T) {
+	h := NewHandler()
+	tests := []struct {
+		path     string
+		body     string
+		expected map[string]interface{}
+	}{
+		{"/flatten", `{"document": ` + doc + `}`, map[string]interface{}{"data": map[string]interface{}{
+			"gamma.omega": "test2", "gamma.ypsilon": 10.0, "gamma.epsilon": nil, "orders.0.total": 150.0, "orders.1.total": 50.0,
+		}}},
+		{"/flatten", `{"document": {"a": {"b": 1}}, "sep": "_"}`, map[string]interface{}{"data": map[string]interface{}{"a_b": 1.0}}},
+		{"/get", `{"document": ` + doc + `, "paths": ["gamma.omega", "orders.1.total", "orders.0"]}`, map[string]interface{}{"values": map[string]interface{}{
+			"gamma.omega": "test2", "orders.1.total": 50.0, "orders.0": map[string]interface{}{"total": 150.0},
+		}}},
+		{"/get", `{"document": ` + doc + `, "paths": ["gamma/omega"], "sep": "/"}`, map[string]interface{}{"values": map[string]interface{}{
+			"gamma/omega": "test2",
+		}}},
+		{"/eval", `{"document": ` + doc + `, "expression": "orders.0.total + orders.1.total > 150"}`, map[string]interface{}{"result": true}},
+	}
+	for _, test := range tests {
+		status, result := post(t, h, test.path, test.body)
+		if status != http.StatusOK {
+			t.Errorf("%v %v must return %v not %v: %v", test.path, test.body, http.StatusOK, status, result)
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%v %v must return %v not %v", test.path, test.body, test.expected, result)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	h := NewHandler(WithMaxBodySize(256))
+	tests := []struct {
+		path   string
+		body   string
+		status int
+		code   string
+		field  string
+	}{
+		{"/get", `{"document": ` + doc + `, "paths": ["gamma.nothing"]}`, http.StatusUnprocessableEntity, CODE_MISSING_FIELD, "gamma.nothing"},
+		{"/get", `{"document": ` + doc + `, "paths": ["orders.5"]}`, http.StatusUnprocessableEntity, CODE_MISSING_FIELD, "orders.5"},
+		{"/get", `{"document": ` + doc + `, "paths": ["gamma.epsilon.delta"]}`, http.StatusUnprocessableEntity, CODE_NIL_ON_PATH, "gamma.epsilon.delta"},
+		{"/get", `{"document": ` + doc + `, "paths": ["gamma.omega.x"]}`, http.StatusUnprocessableEntity, CODE_WRONG_TYPE, "gamma.omega.x"},
+		{"/get", `{"document": ` + doc + `, "paths": ["gamma..omega"]}`, http.StatusBadRequest, CODE_INVALID_PATH, "gamma..omega"},
+		{"/get", `{"document": ` + doc + `, "paths": []}`, http.StatusBadRequest, CODE_BAD_REQUEST, ""},
+		{"/eval", `{"document": ` + doc + `, "expression": "gamma.nothing > 1"}`, http.StatusUnprocessableEntity, CODE_MISSING_FIELD, ""},
+		{"/eval", `{"document": ` + doc + `, "expression": "gamma.omega > 1"}`, http.StatusUnprocessableEntity, CODE_EVAL_FAILED, ""},
+		{"/eval", `{"document": ` + doc + `, "expression": "gamma.omega >"}`, http.StatusBadRequest, CODE_INVALID_EXPRESSION, ""},
+		{"/eval", `{"document": ` + doc + `, "expression": "unknown(gamma.omega)"}`, http.StatusBadRequest, CODE_INVALID_EXPRESSION, ""},
+		{"/eval", `{"document": ` + doc + `}`, http.StatusBadRequest, CODE_BAD_REQUEST, ""},
+		{"/flatten", `{"document": 5}`, http.StatusBadRequest, CODE_BAD_REQUEST, ""},
+		{"/flatten", `{"document": {}, "unknown": 1}`, http.StatusBadRequest, CODE_BAD_REQUEST, ""},
+		{"/flatten", `{"document": {}} {}`, http.StatusBadRequest, CODE_BAD_REQUEST, ""},
+		{"/flatten", `{"document": `, http.StatusBadRequest, CODE_BAD_REQUEST, ""},
+		{"/flatten", `{"document": {"a": "` + strings.Repeat("x", 256) + `"}}`, http.StatusRequestEntityTooLarge, CODE_TOO_LARGE, ""},
+		{"/nothing", `{}`, http.StatusNotFound, CODE_NOT_FOUND, ""},
+	}
+	for _, test := range tests {
+		status, result := post(t, h, test.path, test.body)
+		if status != test.status {
+			t.Errorf("%v %v must return %v not %v: %v", test.path, test.body, test.status, status, result)
+		}
+		body, ok := result["error"].(map[string]interface{})
+		if !ok {
+			t.Errorf("%v %v must return an error body not %v", test.path, test.body, result)
+			continue
+		}
+		if body["code"] != test.code || body["message"] == "" {
+			t.Errorf("%v %v must return code %v not %v", test.path, test.body, test.code, body)
+		}
+		if path, _ := body["path"].(string); path != test.field {
+			t.Errorf("%v %v must return path %q not %q", test.path, test.body, test.field, path)
+		}
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/flatten", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("GET must return %v not %v", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
+
+func TestServer(t *testing.T) {
+	ts := httptest.NewServer(NewHandler())
+	defer ts.Close()
+	resp, err := http.Post(ts.URL+"/get", "application/json", strings.NewReader(`{"document": `+doc+`, "paths": ["gamma.ypsilon"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	result := map[string]map[string]interface{}{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || result["values"]["gamma.ypsilon"] != 10.0 {
+		t.Errorf("gamma.ypsilon must be 10 not %v (%v)", result, resp.StatusCode)
+	}
+}